@@ -2,31 +2,43 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	_ "net/http/pprof"
+	"net/url"
 	"os"
+	"os/signal"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/felixge/fgprof"
 	"github.com/gorilla/mux"
 	"github.com/jpillora/overseer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/updater"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/version"
 	"gopkg.in/alecthomas/kingpin.v2"
 
+	"github.com/trufflesecurity/trufflehog/v3/pkg/baseline"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/config"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/custom_detectors"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/decoders"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	entropyDetector "github.com/trufflesecurity/trufflehog/v3/pkg/detectors/entropy"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/engine"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
 )
 
@@ -40,11 +52,48 @@ var (
 	concurrency    = cli.Flag("concurrency", "Number of concurrent workers.").Default(strconv.Itoa(runtime.NumCPU())).Int()
 	noVerification = cli.Flag("no-verification", "Don't verify the results.").Bool()
 	onlyVerified   = cli.Flag("only-verified", "Only output verified results.").Bool()
-	// rules = cli.Flag("rules", "Path to file with custom rules.").String()
-	printAvgDetectorTime = cli.Flag("print-avg-detector-time", "Print the average time spent on each detector.").Bool()
-	noUpdate             = cli.Flag("no-update", "Don't check for updates.").Bool()
-	fail                 = cli.Flag("fail", "Exit with code 183 if results are found.").Bool()
-	falsePositivesPath   = cli.Flag("false-positives", "Path to file with newline separated strings to ignore as false positives").String()
+	includeUnknown = cli.Flag("include-unknown", "With --only-verified, also output results that could not be verified because of an error.").Bool()
+
+	printAvgDetectorTime    = cli.Flag("print-avg-detector-time", "Print the average time spent on each detector.").Bool()
+	noUpdate                = cli.Flag("no-update", "Don't check for updates.").Bool()
+	fail                    = cli.Flag("fail", "Exit with code 183 if results are found.").Bool()
+	falsePositivesPath      = cli.Flag("false-positives", "Path to file with newline separated strings to ignore as false positives").String()
+	dedupe                  = cli.Flag("dedupe", "Only report the first occurrence of each secret.").Bool()
+	dedupeByLocation        = cli.Flag("dedupe-by-location", "When deduplicating, report each secret once per location instead of once overall.").Bool()
+	verificationConcurrency = cli.Flag("verification-concurrency", "Number of concurrent verification workers.").Default(strconv.Itoa(runtime.NumCPU())).Int()
+	verifyOnlySourceTypes   = cli.Flag("verify-only-source-types", `Only verify the secrets found in sources of these types. You can repeat this flag or separate types with commas. Example: "github,gitlab"`).Strings()
+	noVerifySourceTypes     = cli.Flag("no-verify-source-types", `Don't verify the secrets found in sources of these types. You can repeat this flag or separate types with commas. Example: "s3"`).Strings()
+	noVerifySources         = cli.Flag("no-verify-source", "Don't verify the secrets found in the source with this name, as named in the config of the scan command. You can repeat this flag.").Strings()
+	verifierLimits          = cli.Flag("verifier-limit", `Limit verification of a detector. You can repeat this flag. Example: "aws=2:0.5" for 2 concurrent verifications at 0.5 requests per second.`).Strings()
+	verificationPrivate     = cli.Flag("verification-allow-private-networks", "Allow verification requests to loopback, private, link-local and cloud metadata addresses.").Bool()
+	verificationHosts       = cli.Flag("verification-allowed-hosts", `Only allow the verification requests of a detector to go to these hosts. You can repeat this flag. Example: "uri=*.example.com,example.com"`).Strings()
+	verificationProxy       = cli.Flag("verification-proxy", "URL of an HTTP proxy to send verification requests through.").String()
+	verificationCache       = cli.Flag("verification-cache", "Reuse verification results for secrets that have already been verified.").Bool()
+	verificationCacheTTL    = cli.Flag("verification-cache-ttl", "How long verification results are reused for. 0 never expires them.").Default("24h").Duration()
+	verificationCacheFile   = cli.Flag("verification-cache-file", "Path to a file to persist verification results between runs. Enables the verification cache.").String()
+	includeDetectors        = cli.Flag("include-detectors", `Only run these detectors, by name or by tag. Custom detectors can be selected as "CustomRegex/NAME". You can repeat this flag or separate names with commas. Example: "aws,gcp,tag:payment"`).Strings()
+	excludeDetectors        = cli.Flag("exclude-detectors", `Don't run these detectors, by name or by tag. You can repeat this flag or separate names with commas. Example: "generic,tag:ci"`).Strings()
+	metricsAddr             = cli.Flag("metrics-addr", `Address to serve Prometheus metrics on at /metrics while scanning. Example: ":9090"`).String()
+	entropy                 = cli.Flag("entropy", "Also report high entropy base64 and hex strings.").Bool()
+	entropyBase64Threshold  = cli.Flag("entropy-base64-threshold", "Minimum Shannon entropy of reported base64 strings, in bits per character.").Default("4.5").Float64()
+	entropyBase64MinLength  = cli.Flag("entropy-base64-min-length", "Minimum length of reported base64 strings.").Default("20").Int()
+	entropyHexThreshold     = cli.Flag("entropy-hex-threshold", "Minimum Shannon entropy of reported hex strings, in bits per character.").Default("3").Float64()
+	entropyHexMinLength     = cli.Flag("entropy-hex-min-length", "Minimum length of reported hex strings.").Default("20").Int()
+	rules                   = cli.Flag("rules", `Path to a YAML file declaring custom regex detectors. Their results have the "CustomRegex" detector type.`).String()
+	resumeFile              = cli.Flag("resume", "Path to a file to save the progress of the scan to periodically and when it is interrupted. If the file exists, the scan continues where it left off.").String()
+	sinkSpecs               = cli.Flag("sink", `Write the results to a sink, instead of the format chosen by --json or --json-legacy. You can repeat this flag. Example: "plain" or "json=results.json"`).Strings()
+	baselineFile            = cli.Flag("baseline", "Path to a baseline file, or the JSON output of a previous scan. Only secrets that aren't in it are reported.").String()
+	archiveMaxDepth         = cli.Flag("archive-max-depth", "Number of nested archives and compressed files to extract. A tar.gz file counts as two.").Default("5").Int()
+	archiveMaxSize          = cli.Flag("archive-max-size", "Maximum amount of data to extract from a single archive.").Default("250MB").Bytes()
+	archiveTimeout          = cli.Flag("archive-timeout", "Maximum time to spend extracting and scanning a single archive.").Default("5m").Duration()
+	decodeDepth             = cli.Flag("decode-depth", "Number of times to decode data that was already decoded, such as base64 inside base64. 1 only decodes the scanned data.").Default("3").Int()
+
+	detectorsList = cli.Command("detectors", "List the available detectors and their keywords.")
+
+	baselineCmd        = cli.Command("baseline", "Create or update a baseline of known secrets from the JSON output of scans.")
+	baselineCmdOutput  = baselineCmd.Flag("output", "Path to the baseline file to write.").Short('o').Required().String()
+	baselineCmdUpdate  = baselineCmd.Flag("update", "Keep the secrets that are already in the baseline file, instead of replacing them.").Bool()
+	baselineCmdResults = baselineCmd.Arg("results", "Files with the --json output of scans. Standard input is read if there are none.").Strings()
 
 	gitScan             = cli.Command("git", "Find credentials in git repositories.")
 	gitScanURI          = gitScan.Arg("uri", "Git repository URL. https:// or file:// schema expected.").Required().String()
@@ -54,7 +103,6 @@ var (
 	gitScanBranch       = gitScan.Flag("branch", "Branch to scan.").String()
 	gitScanMaxDepth     = gitScan.Flag("max-depth", "Maximum depth of commits to scan.").Int()
 	_                   = gitScan.Flag("allow", "No-op flag for backwards compat.").Bool()
-	_                   = gitScan.Flag("entropy", "No-op flag for backwards compat.").Bool()
 	_                   = gitScan.Flag("regex", "No-op flag for backwards compat.").Bool()
 
 	githubScan           = cli.Command("github", "Find credentials in GitHub repositories.")
@@ -83,6 +131,9 @@ var (
 	s3ScanSecret   = s3Scan.Flag("secret", "S3 secret used to authenticate.").String()
 	s3ScanCloudEnv = s3Scan.Flag("cloud-environment", "Use IAM credentials in cloud environment.").Bool()
 	s3ScanBuckets  = s3Scan.Flag("bucket", "Name of S3 bucket to scan. You can repeat this flag.").Strings()
+
+	configScan     = cli.Command("scan", "Find credentials in the sources declared in a configuration file.")
+	configScanPath = configScan.Flag("config", "Path to the YAML file declaring the sources, detectors, filters and output of the scan.").Required().String()
 )
 
 func init() {
@@ -161,14 +212,183 @@ func run(state overseer.State) {
 		}()
 	}
 
+	if cmd == baselineCmd.FullCommand() {
+		if err := writeBaseline(*baselineCmdOutput, *baselineCmdUpdate, *baselineCmdResults); err != nil {
+			logrus.WithError(err).Fatal("could not write baseline")
+		}
+		return
+	}
+
+	var scanConfig *config.Config
+	if cmd == configScan.FullCommand() {
+		var err error
+		scanConfig, err = config.Read(*configScanPath)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not load scan config")
+		}
+		applyConfig(scanConfig)
+	}
+
+	include, err := engine.ParseDetectorSelector(*includeDetectors)
+	if err != nil {
+		logrus.WithError(err).Fatal("invalid included detectors")
+	}
+	exclude, err := engine.ParseDetectorSelector(*excludeDetectors)
+	if err != nil {
+		logrus.WithError(err).Fatal("invalid excluded detectors")
+	}
+
+	var customDetectors []detectors.Detector
+	if *rules != "" {
+		customDetectors, err = custom_detectors.Read(*rules)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not load custom detectors")
+		}
+	}
+	if scanConfig != nil {
+		configured, err := custom_detectors.New(scanConfig.CustomDetectors...)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not load custom detectors")
+		}
+		customDetectors = append(customDetectors, configured...)
+	}
+	allDetectors := append(engine.DefaultDetectors(), customDetectors...)
+	// The entropy detector has nothing to verify, and it matches every chunk, so it is never queued for verification.
+	var unverifiedDetectors []detectors.Detector
+	if *entropy {
+		unverifiedDetectors = append(unverifiedDetectors, entropyDetector.New(entropyDetector.Config{
+			Base64Threshold: *entropyBase64Threshold,
+			Base64MinLength: *entropyBase64MinLength,
+			HexThreshold:    *entropyHexThreshold,
+			HexMinLength:    *entropyHexMinLength,
+		}))
+	}
+
+	if cmd == detectorsList.FullCommand() {
+		printDetectors(engine.SelectDetectorsBy(append(allDetectors, unverifiedDetectors...), include, exclude))
+		return
+	}
+
 	detectors.SetCustomFalsePositivesFilename(strings.TrimSpace(*falsePositivesPath))
 
+	allowedHosts, err := common.ParseAllowedHosts(*verificationHosts)
+	if err != nil {
+		logrus.WithError(err).Fatal("invalid verification allowed hosts")
+	}
+	egressPolicy := common.EgressPolicy{
+		AllowPrivateNetworks: *verificationPrivate,
+		AllowedHosts:         allowedHosts,
+	}
+	if *verificationProxy != "" {
+		egressPolicy.Proxy, err = url.Parse(*verificationProxy)
+		if err != nil || egressPolicy.Proxy.Host == "" {
+			logrus.WithError(err).Fatalf("invalid verification proxy %q", *verificationProxy)
+		}
+	}
+	common.SetEgressPolicy(egressPolicy)
+
+	if *archiveMaxDepth < 0 || *archiveMaxSize <= 0 || *archiveTimeout <= 0 {
+		logrus.Fatal("invalid archive limits, the size and timeout must be positive")
+	}
+	handlers.SetLimits(handlers.Limits{
+		MaxDepth: *archiveMaxDepth,
+		MaxSize:  int64(*archiveMaxSize),
+		Timeout:  *archiveTimeout,
+	})
+
+	if *decodeDepth < 1 {
+		logrus.Fatalf("invalid decode depth %d, must be at least 1", *decodeDepth)
+	}
+
 	ctx := context.TODO()
-	e := engine.Start(ctx,
+	engineOpts := []engine.EngineOption{
 		engine.WithConcurrency(*concurrency),
 		engine.WithDecoders(decoders.DefaultDecoders()...),
-		engine.WithDetectors(!*noVerification, engine.DefaultDetectors()...),
+		engine.WithDecodeDepth(*decodeDepth),
+		engine.WithDetectors(!*noVerification, allDetectors...),
+		engine.WithDetectors(false, unverifiedDetectors...),
+		engine.WithDetectorSelectors(include, exclude),
+	}
+	limits := map[detectorspb.DetectorType]engine.VerifierLimit{}
+	for _, l := range *verifierLimits {
+		detectorType, limit, err := engine.ParseVerifierLimit(l)
+		if err != nil {
+			logrus.WithError(err).Fatal("invalid verifier limit")
+		}
+		limits[detectorType] = limit
+	}
+	engineOpts = append(engineOpts,
+		engine.WithVerificationConcurrency(*verificationConcurrency),
+		engine.WithVerifierLimits(engine.VerifierLimit{}, limits),
 	)
+	if len(*verifyOnlySourceTypes) > 0 || len(*noVerifySourceTypes) > 0 || len(*noVerifySources) > 0 {
+		policy := &engine.VerificationPolicy{SkipSourceNames: *noVerifySources}
+		if policy.OnlySourceTypes, err = engine.ParseSourceTypes(*verifyOnlySourceTypes); err != nil {
+			logrus.WithError(err).Fatal("invalid source types to verify")
+		}
+		if policy.SkipSourceTypes, err = engine.ParseSourceTypes(*noVerifySourceTypes); err != nil {
+			logrus.WithError(err).Fatal("invalid source types not to verify")
+		}
+		engineOpts = append(engineOpts, engine.WithVerificationPolicy(policy))
+	}
+	if *dedupe || *dedupeByLocation {
+		engineOpts = append(engineOpts, engine.WithDeduplication(*dedupeByLocation))
+	}
+	var cache *engine.VerificationCache
+	if *verificationCache || *verificationCacheFile != "" {
+		var err error
+		cache, err = engine.NewVerificationCache(*verificationCacheTTL, *verificationCacheFile)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not create verification cache")
+		}
+		engineOpts = append(engineOpts, engine.WithVerificationCache(cache))
+	}
+	var knownSecrets *baseline.Baseline
+	if *baselineFile != "" {
+		knownSecrets, err = baseline.Read(*baselineFile)
+		if err != nil {
+			logrus.WithError(err).Fatal("could not load baseline")
+		}
+		logrus.Debugf("loaded baseline with %d secrets", knownSecrets.Len())
+		engineOpts = append(engineOpts, engine.WithBaseline(knownSecrets))
+	}
+	if scanConfig != nil {
+		pathFilter, err := scanConfig.Filters.Filter()
+		if err != nil {
+			logrus.WithError(err).Fatal("could not create filter")
+		}
+		engineOpts = append(engineOpts, engine.WithPathFilter(pathFilter))
+	}
+
+	// With a resume file, an interrupt only stops the sources. The chunks they already sent are still scanned so the
+	// saved state is consistent. A second interrupt exits immediately.
+	sourcesCtx := ctx
+	var resumeState *engine.ResumeState
+	if *resumeFile != "" {
+		resumeState, err = engine.LoadResumeState(*resumeFile, resumeScanID(os.Args[1:]))
+		if err != nil {
+			logrus.WithError(err).Fatal("could not load resume state")
+		}
+		engineOpts = append(engineOpts, engine.WithResumeState(resumeState))
+
+		var stop context.CancelFunc
+		sourcesCtx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-sourcesCtx.Done()
+			stop()
+			logrus.Info("stopping the scan, interrupt again to exit without saving the progress")
+		}()
+	}
+	e := engine.Start(ctx, engineOpts...)
+	stopCheckpoints := func() {}
+	if resumeState != nil {
+		stopCheckpoints = checkpointPeriodically(resumeState)
+	}
+
+	if *metricsAddr != "" {
+		go serveMetrics(*metricsAddr, e)
+	}
 
 	filter, err := common.FilterFromFiles(*gitScanIncludePaths, *gitScanExcludePaths, true)
 	if err != nil {
@@ -186,7 +406,7 @@ func run(state overseer.State) {
 		if remote {
 			defer os.RemoveAll(repoPath)
 		}
-		err = e.ScanGit(ctx, repoPath, *gitScanBranch, *gitScanSinceCommit, *gitScanMaxDepth, filter)
+		err = e.ScanGit(sourcesCtx, repoPath, *gitScanBranch, *gitScanSinceCommit, *gitScanMaxDepth, filter)
 		if err != nil {
 			logrus.WithError(err).Fatal("Failed to scan git.")
 		}
@@ -194,68 +414,282 @@ func run(state overseer.State) {
 		if len(*githubScanOrgs) == 0 && len(*githubScanRepos) == 0 {
 			log.Fatal("You must specify at least one organization or repository.")
 		}
-		err = e.ScanGitHub(ctx, *githubScanEndpoint, *githubScanRepos, *githubScanOrgs, *githubScanToken, *githubIncludeForks, filter, *concurrency, *githubIncludeMembers)
+		err = e.ScanGitHub(sourcesCtx, *githubScanEndpoint, *githubScanRepos, *githubScanOrgs, *githubScanToken, *githubIncludeForks, filter, *concurrency, *githubIncludeMembers)
 		if err != nil {
 			logrus.WithError(err).Fatal("Failed to scan git.")
 		}
 	case gitlabScan.FullCommand():
-		err := e.ScanGitLab(ctx, *gitlabScanEndpoint, *gitlabScanToken, *gitlabScanRepos)
+		err := e.ScanGitLab(sourcesCtx, *gitlabScanEndpoint, *gitlabScanToken, *gitlabScanRepos)
 		if err != nil {
 			logrus.WithError(err).Fatal("Failed to scan GitLab.")
 		}
 	case filesystemScan.FullCommand():
-		err := e.ScanFileSystem(ctx, *filesystemDirectories)
+		err := e.ScanFileSystem(sourcesCtx, *filesystemDirectories)
 		if err != nil {
 			logrus.WithError(err).Fatal("Failed to scan filesystem")
 		}
 	case s3Scan.FullCommand():
-		err := e.ScanS3(ctx, *s3ScanKey, *s3ScanSecret, *s3ScanCloudEnv, *s3ScanBuckets)
+		err := e.ScanS3(sourcesCtx, *s3ScanKey, *s3ScanSecret, *s3ScanCloudEnv, *s3ScanBuckets)
 		if err != nil {
 			logrus.WithError(err).Fatal("Failed to scan S3.")
 		}
+	case configScan.FullCommand():
+		for _, source := range scanConfig.Sources {
+			err := e.ScanSource(sourcesCtx, source.Name, source.Connection, source.Verify, *concurrency)
+			if err != nil {
+				logrus.WithError(err).Fatalf("Failed to scan %s.", source.Name)
+			}
+		}
 	}
 
+	// Wait for the sources to finish in the background so results are printed as soon as they are found.
+	go e.Finish()
+
 	if !*jsonLegacy && !*jsonOut {
 		fmt.Fprintf(os.Stderr, "🐷🔑🐷  TruffleHog. Unearth your secrets. 🐷🔑🐷\n\n")
 	}
 
+	sink, err := newSink(*sinkSpecs, output.SinkConfig{RepoPath: repoPath})
+	if err != nil {
+		logrus.WithError(err).Fatal("could not create output")
+	}
+	if err := sink.Open(ctx); err != nil {
+		logrus.WithError(err).Fatal("could not open output")
+	}
+
 	foundResults := false
 	for r := range e.ResultsChan() {
-		if *onlyVerified && !r.Verified {
+		if *onlyVerified && !r.Verified && !(*includeUnknown && r.VerificationState() == detectors.StateUnknown) {
 			continue
 		}
 		foundResults = true
 
-		switch {
-		case *jsonLegacy:
-			legacy := output.ConvertToLegacyJSON(&r, repoPath)
-			out, err := json.Marshal(legacy)
-			if err != nil {
-				logrus.WithError(err).Fatal("could not marshal result")
-			}
-			fmt.Println(string(out))
-		case *jsonOut:
-			out, err := json.Marshal(r)
-			if err != nil {
-				logrus.WithError(err).Fatal("could not marshal result")
-			}
-			fmt.Println(string(out))
-		default:
-			output.PrintPlainOutput(&r, false)
+		if err := sink.Write(ctx, &r); err != nil {
+			logrus.WithError(err).Fatal("could not output result")
+		}
+	}
+	if err := sink.Close(); err != nil {
+		logrus.WithError(err).Error("could not close output")
+	}
+	scanErr := e.Wait()
+	if scanErr != nil {
+		logrus.WithError(scanErr).Error("errors occurred while scanning")
+	}
+	stopCheckpoints()
+	if resumeState != nil {
+		saveResumeState(resumeState, sourcesCtx.Err() != nil || scanErr != nil)
+	}
+	metrics := e.Metrics()
+	logrus.Debugf("scanned %d chunks and %d bytes in %s", metrics.ChunksScanned, metrics.BytesScanned, metrics.ScanDuration)
+	var ignored uint64
+	for _, d := range metrics.Detectors {
+		ignored += d.Ignored
+	}
+	if ignored > 0 {
+		logrus.Debugf("ignored %d results with inline trufflehog:ignore annotations", ignored)
+	}
+	if knownSecrets != nil {
+		logrus.Debugf("skipped %d results that are in the baseline", knownSecrets.Matched())
+	}
+
+	if cache != nil {
+		logrus.Debugf("verification cache has %d entries", cache.Len())
+		if err := cache.Save(); err != nil {
+			logrus.WithError(err).Error("could not save verification cache")
 		}
 	}
-	logrus.Debugf("scanned %d chunks", e.ChunksScanned())
 
 	if *printAvgDetectorTime {
 		printAverageDetectorTime(e)
 	}
 
+	if (*dedupe || *dedupeByLocation) && !*jsonLegacy && !*jsonOut {
+		printDedupeSummary(e)
+	}
+
 	if foundResults && *fail {
 		logrus.Debug("exiting with code 183 because results were found")
 		os.Exit(183)
 	}
 }
 
+// applyConfig merges the detector selection, verification and output settings of the config into the flags. Detectors
+// selected by the flags are added to the ones in the config.
+func applyConfig(c *config.Config) {
+	*includeDetectors = append(*includeDetectors, c.Detectors.Include...)
+	*excludeDetectors = append(*excludeDetectors, c.Detectors.Exclude...)
+	*verifyOnlySourceTypes = append(*verifyOnlySourceTypes, c.Verification.OnlySourceTypes...)
+	*noVerifySourceTypes = append(*noVerifySourceTypes, c.Verification.SkipSourceTypes...)
+	*noVerifySources = append(*noVerifySources, c.Verification.SkipSources...)
+
+	switch c.Output.Format {
+	case config.FormatJSON:
+		*jsonOut = true
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	case config.FormatJSONLegacy:
+		*jsonLegacy = true
+	}
+	*onlyVerified = *onlyVerified || c.Output.OnlyVerified
+	*includeUnknown = *includeUnknown || c.Output.IncludeUnknown
+	*dedupe = *dedupe || c.Output.Dedupe
+	*dedupeByLocation = *dedupeByLocation || c.Output.DedupeByLocation
+	*sinkSpecs = append(*sinkSpecs, c.Output.Sinks...)
+}
+
+// resumeCheckpointInterval is how often the resume state is saved while scanning, so that a scan that is killed can
+// still be resumed.
+const resumeCheckpointInterval = time.Minute
+
+// checkpointPeriodically saves the resume state every resumeCheckpointInterval until the returned function is called.
+func checkpointPeriodically(state *engine.ResumeState) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(resumeCheckpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := state.Checkpoint(); err != nil {
+					logrus.WithError(err).Error("could not save resume file")
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// saveResumeState saves the state if the scan didn't finish, and removes it otherwise.
+func saveResumeState(state *engine.ResumeState, interrupted bool) {
+	if !interrupted {
+		if err := state.Remove(); err != nil {
+			logrus.WithError(err).Error("could not remove resume file")
+		}
+		return
+	}
+	if err := state.Save(); err != nil {
+		logrus.WithError(err).Error("could not save resume file")
+		return
+	}
+	logrus.Infof("saved the progress of the scan, run the same command again to resume it")
+}
+
+// newSink creates the sinks of the specs, or the sink of the output format flags if there are none.
+func newSink(specs []string, config output.SinkConfig) (output.Sink, error) {
+	if len(specs) == 0 {
+		switch {
+		case *jsonLegacy:
+			specs = []string{output.SinkJSONLegacy}
+		case *jsonOut:
+			specs = []string{output.SinkJSON}
+		default:
+			specs = []string{output.SinkPlain}
+		}
+	}
+	sinks := make(output.MultiSink, 0, len(specs))
+	for _, spec := range specs {
+		sink, err := output.ParseSink(spec, config)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// writeBaseline writes the baseline of the results in the JSON output of scans to path.
+func writeBaseline(path string, update bool, resultFiles []string) error {
+	b := baseline.New()
+	if _, err := os.Stat(path); update && err == nil {
+		b, err = baseline.Read(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	readResults := func(name string, r io.Reader) error {
+		findings, err := baseline.ReadResults(r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		for _, f := range findings {
+			b.Add(f)
+		}
+		return nil
+	}
+	if len(resultFiles) == 0 {
+		if err := readResults("standard input", os.Stdin); err != nil {
+			return err
+		}
+	}
+	for _, name := range resultFiles {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = readResults(name, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := b.Save(path); err != nil {
+		return err
+	}
+	logrus.Infof("wrote %d secrets to baseline %s", b.Len(), path)
+	return nil
+}
+
+// resumeScanID describes the scan by its arguments, without the resume flag, so a resume file is only used to
+// continue the same scan.
+func resumeScanID(args []string) string {
+	var id []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--resume":
+			i++
+		case strings.HasPrefix(args[i], "--resume="):
+		default:
+			id = append(id, args[i])
+		}
+	}
+	return strings.Join(id, " ")
+}
+
+func serveMetrics(addr string, e *engine.Engine) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(e.PrometheusCollector())
+	router := mux.NewRouter()
+	router.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	logrus.Infof("serving metrics on %s/metrics", addr)
+	if err := http.ListenAndServe(addr, router); err != nil {
+		logrus.WithError(err).Error("could not serve metrics")
+	}
+}
+
+func printDetectors(ds []detectors.Detector) {
+	sort.Slice(ds, func(i, j int) bool {
+		return detectors.Type(ds[i]).String() < detectors.Type(ds[j]).String()
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DETECTOR\tTAGS\tKEYWORDS")
+	for _, d := range ds {
+		name := detectors.Type(d).String()
+		if custom, ok := d.(*custom_detectors.Regex); ok {
+			name += " (" + custom.Name() + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, strings.Join(engine.DetectorTags(d), ", "), strings.Join(d.Keywords(), ", "))
+	}
+	w.Flush()
+}
+
 func printAverageDetectorTime(e *engine.Engine) {
 	fmt.Fprintln(os.Stderr, "Average detector time is the measurement of average time spent on each detector when results are returned.")
 	for detectorName, durations := range e.DetectorAvgTime() {
@@ -267,3 +701,22 @@ func printAverageDetectorTime(e *engine.Engine) {
 		fmt.Fprintf(os.Stderr, "%s: %s\n", detectorName, avgDuration)
 	}
 }
+
+func printDedupeSummary(e *engine.Engine) {
+	results := e.DedupedResults()
+	occurrences := 0
+	for _, r := range results {
+		occurrences += r.Count
+	}
+	fmt.Fprintf(os.Stderr, "Found %d unique secrets across %d occurrences.\n", len(results), occurrences)
+	for _, r := range results {
+		if r.Count < 2 {
+			continue
+		}
+		secret := r.Redacted
+		if secret == "" {
+			secret = string(r.Raw)
+		}
+		fmt.Fprintf(os.Stderr, "%s %s: %d occurrences\n", r.DetectorType.String(), strings.TrimSpace(secret), r.Count)
+	}
+}
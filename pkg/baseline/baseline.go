@@ -0,0 +1,222 @@
+// Package baseline records the secrets that were already known when a scan was adopted, so that later scans only
+// report new ones.
+package baseline
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-errors/errors"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// version is the version of the file format.
+const version = 1
+
+// Finding is a secret in the baseline. Only the fingerprint is used to match results; the other fields help people
+// reviewing the baseline, and the secret itself isn't stored.
+type Finding struct {
+	Fingerprint  string `json:"fingerprint"`
+	DetectorType string `json:"detector_type"`
+	File         string `json:"file,omitempty"`
+	Redacted     string `json:"redacted,omitempty"`
+}
+
+// Baseline is a set of known secrets.
+type Baseline struct {
+	// matched is first so it is 64-bit aligned for atomic operations.
+	matched  uint64
+	mu       sync.RWMutex
+	findings map[string]Finding
+}
+
+type baselineFile struct {
+	Version  int       `json:"version"`
+	Findings []Finding `json:"findings"`
+}
+
+// New returns an empty baseline.
+func New() *Baseline {
+	return &Baseline{findings: map[string]Finding{}}
+}
+
+// Read reads the baseline saved at path. The file can also be the JSON output of a previous scan, in which case the
+// baseline holds its results.
+func Read(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not read baseline", 0)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&fields); err != nil && err != io.EOF {
+		return nil, errors.WrapPrefix(err, "could not parse baseline", 0)
+	}
+	b := New()
+	if _, ok := fields["findings"]; !ok {
+		findings, err := ReadResults(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "could not parse baseline", 0)
+		}
+		for _, f := range findings {
+			b.Add(f)
+		}
+		return b, nil
+	}
+
+	var file baselineFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errors.WrapPrefix(err, "could not parse baseline", 0)
+	}
+	if file.Version != version {
+		return nil, errors.Errorf("baseline %s has unsupported version %d", path, file.Version)
+	}
+	for _, f := range file.Findings {
+		if f.Fingerprint == "" {
+			return nil, errors.Errorf("baseline %s has a finding without a fingerprint", path)
+		}
+		b.Add(f)
+	}
+	return b, nil
+}
+
+// jsonResult holds the fields of a result in the JSON output that make up its finding.
+type jsonResult struct {
+	DetectorType   detectorspb.DetectorType
+	Raw            []byte
+	ExtraData      map[string]string
+	Redacted       string
+	SourceMetadata struct {
+		// Data holds the metadata of the source under its type, such as Git or Filesystem.
+		Data map[string]struct {
+			File string `json:"file"`
+		}
+	}
+}
+
+// ReadResults returns the findings of the results in the JSON output of a scan.
+func ReadResults(r io.Reader) ([]Finding, error) {
+	var findings []Finding
+	decoder := json.NewDecoder(r)
+	for {
+		var result jsonResult
+		err := decoder.Decode(&result)
+		if err == io.EOF {
+			return findings, nil
+		}
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "could not parse results", 0)
+		}
+		if len(result.Raw) == 0 {
+			return nil, errors.Errorf("result %d has no raw secret, only the --json output format is supported", len(findings)+1)
+		}
+		var file string
+		for _, metadata := range result.SourceMetadata.Data {
+			file = metadata.File
+		}
+		detectorName := detectors.Result{DetectorType: result.DetectorType, ExtraData: result.ExtraData}.DetectorName()
+		findings = append(findings, Finding{
+			Fingerprint:  fingerprint(detectorName, result.Raw, file),
+			DetectorType: detectorName,
+			File:         file,
+			Redacted:     result.Redacted,
+		})
+	}
+}
+
+// Fingerprint identifies a secret by its detector name, the raw secret and the file it was found in. The line and
+// commit aren't part of it, so the fingerprint doesn't change when lines are added above the secret or the file is
+// changed in a later commit.
+func Fingerprint(r *detectors.ResultWithMetadata) string {
+	return fingerprint(r.DetectorName(), r.Raw, sources.MetadataFile(r.SourceMetadata))
+}
+
+func fingerprint(detectorName string, raw []byte, file string) string {
+	h := sha256.New()
+	h.Write([]byte(detectorName))
+	h.Write([]byte{0})
+	h.Write(raw)
+	h.Write([]byte{0})
+	h.Write([]byte(file))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewFinding returns the finding of the result.
+func NewFinding(r *detectors.ResultWithMetadata) Finding {
+	return Finding{
+		Fingerprint:  Fingerprint(r),
+		DetectorType: r.DetectorName(),
+		File:         sources.MetadataFile(r.SourceMetadata),
+		Redacted:     r.Redacted,
+	}
+}
+
+// Add adds the finding to the baseline.
+func (b *Baseline) Add(f Finding) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.findings[f.Fingerprint] = f
+}
+
+// Contains returns true if the result is in the baseline.
+func (b *Baseline) Contains(r *detectors.ResultWithMetadata) bool {
+	b.mu.RLock()
+	_, ok := b.findings[Fingerprint(r)]
+	b.mu.RUnlock()
+	if ok {
+		atomic.AddUint64(&b.matched, 1)
+	}
+	return ok
+}
+
+// Len returns the number of findings in the baseline.
+func (b *Baseline) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.findings)
+}
+
+// Matched returns the number of results Contains found in the baseline.
+func (b *Baseline) Matched() uint64 {
+	return atomic.LoadUint64(&b.matched)
+}
+
+// Save writes the baseline to path, with the findings sorted so that changes to it are easy to review.
+func (b *Baseline) Save(path string) error {
+	b.mu.RLock()
+	file := baselineFile{Version: version, Findings: make([]Finding, 0, len(b.findings))}
+	for _, f := range b.findings {
+		file.Findings = append(file.Findings, f)
+	}
+	b.mu.RUnlock()
+	sort.Slice(file.Findings, func(i, j int) bool {
+		fi, fj := file.Findings[i], file.Findings[j]
+		if fi.File != fj.File {
+			return fi.File < fj.File
+		}
+		if fi.DetectorType != fj.DetectorType {
+			return fi.DetectorType < fj.DetectorType
+		}
+		return fi.Fingerprint < fj.Fingerprint
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return errors.WrapPrefix(err, "could not marshal baseline", 0)
+	}
+	if err := common.WriteFileAtomic(path, append(data, '\n')); err != nil {
+		return errors.WrapPrefix(err, "could not save baseline", 0)
+	}
+	return nil
+}
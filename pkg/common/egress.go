@@ -0,0 +1,285 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-errors/errors"
+)
+
+// ErrEgressBlocked is returned for verification requests that the egress policy doesn't allow.
+var ErrEgressBlocked = errors.New("verification request blocked by egress policy")
+
+// EgressPolicy restricts where the requests that verify secrets can go. Secrets are found in untrusted data, so
+// without it a URL in a scanned file could make the scanner call services on its own network, such as a cloud
+// metadata endpoint.
+//
+// The policy applies to requests made with a context returned by WithVerification through the clients of this
+// package, which is how the engine verifies results. Requests on the default transport or another *http.Transport,
+// such as the one of PinnedRetryableHttpClient, are sent on a copy of it that dials and proxies them according to the
+// policy. Other round trippers wrapped in a CustomTransport only get the checks made before a request is sent: the
+// allowed hosts, and the addresses the host resolves to. Detectors that don't use HTTP don't go through the policy at
+// all, which is why the URI detector only verifies http and https URIs.
+type EgressPolicy struct {
+	// AllowPrivateNetworks allows requests to loopback, private, link-local and other non-public addresses.
+	AllowPrivateNetworks bool
+	// AllowedHosts restricts the hosts the verification requests of a detector can go to, by lowercase detector name,
+	// such as "aws" or "customregex/internal token". The entry of a detector type, such as "customregex", applies to
+	// the detectors of that type without their own entry. A host can start with "*." to allow its subdomains.
+	// Detectors without an entry can reach any host.
+	AllowedHosts map[string][]string
+	// Proxy routes verification requests through an HTTP proxy instead of the proxy of the environment. The proxy
+	// itself may be on a private network.
+	Proxy *url.URL
+}
+
+var (
+	egressMu     sync.RWMutex
+	egressPolicy EgressPolicy
+)
+
+// SetEgressPolicy sets the policy of all verification requests.
+func SetEgressPolicy(policy EgressPolicy) {
+	egressMu.Lock()
+	egressPolicy = policy
+	egressMu.Unlock()
+
+	// Connections that were allowed by the previous policy aren't reused.
+	egressTransport.CloseIdleConnections()
+	egressTransports.Range(func(_, transport interface{}) bool {
+		transport.(*http.Transport).CloseIdleConnections()
+		return true
+	})
+	proxyAddresses.Range(func(address, _ interface{}) bool {
+		proxyAddresses.Delete(address)
+		return true
+	})
+}
+
+func currentEgressPolicy() EgressPolicy {
+	egressMu.RLock()
+	defer egressMu.RUnlock()
+	return egressPolicy
+}
+
+// ParseAllowedHosts parses allowed hosts of the form "detector=host1,host2" into the AllowedHosts of a policy.
+func ParseAllowedHosts(specs []string) (map[string][]string, error) {
+	allowed := map[string][]string{}
+	for _, spec := range specs {
+		split := strings.SplitN(spec, "=", 2)
+		if len(split) != 2 || strings.TrimSpace(split[0]) == "" {
+			return nil, errors.Errorf("invalid allowed hosts %q, must be of the form detector=host1,host2", spec)
+		}
+		detector := strings.ToLower(strings.TrimSpace(split[0]))
+		for _, host := range strings.Split(split[1], ",") {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if host == "" {
+				continue
+			}
+			allowed[detector] = append(allowed[detector], host)
+		}
+		if len(allowed[detector]) == 0 {
+			return nil, errors.Errorf("invalid allowed hosts %q, no hosts given", spec)
+		}
+	}
+	return allowed, nil
+}
+
+type verificationKey struct{}
+
+// WithVerification returns a context for the verification requests of a detector, which are subject to the egress
+// policy. detector is the detector name, such as "URI" or "CustomRegex/internal token".
+func WithVerification(ctx context.Context, detector string) context.Context {
+	return context.WithValue(ctx, verificationKey{}, strings.ToLower(detector))
+}
+
+func verificationDetector(ctx context.Context) (string, bool) {
+	detector, ok := ctx.Value(verificationKey{}).(string)
+	return detector, ok
+}
+
+// allowedHosts returns the hosts the detector is allowed to reach, falling back to the entry of its type.
+func (p EgressPolicy) allowedHosts(detector string) ([]string, bool) {
+	if allowed, ok := p.AllowedHosts[detector]; ok {
+		return allowed, true
+	}
+	if i := strings.Index(detector, "/"); i >= 0 {
+		allowed, ok := p.AllowedHosts[detector[:i]]
+		return allowed, ok
+	}
+	return nil, false
+}
+
+// checkEgress returns an error if the policy doesn't allow the verification request of the detector.
+func (p EgressPolicy) checkEgress(ctx context.Context, detector string, req *http.Request) error {
+	host := strings.ToLower(req.URL.Hostname())
+	if allowed, ok := p.allowedHosts(detector); ok && !hostAllowed(host, allowed) {
+		return fmt.Errorf("%w: %s is not an allowed host for the %s detector", ErrEgressBlocked, host, detector)
+	}
+	if p.AllowPrivateNetworks {
+		return nil
+	}
+	// Without a proxy, the addresses are checked when dialing, after they are resolved. Through a proxy, they have to
+	// be checked beforehand since the proxy resolves the host.
+	if proxy, err := p.proxy(req); err != nil || proxy == nil {
+		return err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if blockedIP(ip.IP) {
+			return fmt.Errorf("%w: %s resolves to non-public address %s", ErrEgressBlocked, host, ip.IP)
+		}
+	}
+	return nil
+}
+
+func hostAllowed(host string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if pattern == host {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// nonPublicNetworks are the networks that the net.IP methods don't cover.
+var nonPublicNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // "this" network
+		"100.64.0.0/10", // carrier-grade NAT
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved
+		"64:ff9b::/96",  // NAT64, which can map to any IPv4 address
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// blockedIP returns true if the address isn't a public unicast address. Cloud metadata services are on link-local
+// addresses, such as 169.254.169.254 and fd00:ec2::254, which are covered as link-local and private addresses.
+func blockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// egressDialControl rejects connections to non-public addresses. It runs after the host is resolved, so a host that
+// resolves to a public address when checked and a private one when connecting can't get around it.
+func egressDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || blockedIP(ip) {
+		return fmt.Errorf("%w: connection to non-public address %s", ErrEgressBlocked, host)
+	}
+	return nil
+}
+
+// proxy returns the proxy of the verification request, or nil if it isn't proxied. Without a proxy in the policy,
+// the proxy of the environment is used, like for other requests.
+func (p EgressPolicy) proxy(req *http.Request) (*url.URL, error) {
+	if p.Proxy != nil {
+		return p.Proxy, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// proxyAddresses holds the addresses of the proxies that verification requests were sent through, which are trusted
+// when dialing.
+var proxyAddresses sync.Map
+
+// proxyAddress returns the address the transport dials for the proxy.
+func proxyAddress(proxy *url.URL) string {
+	port := proxy.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443", "socks5": "1080"}[proxy.Scheme]
+	}
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
+
+// egressTransport is used for the verification requests of the clients of this package.
+var egressTransport = &http.Transport{
+	Proxy: func(req *http.Request) (*url.URL, error) {
+		proxy, err := currentEgressPolicy().proxy(req)
+		if proxy != nil {
+			proxyAddresses.Store(proxyAddress(proxy), struct{}{})
+		}
+		return proxy, err
+	},
+	DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		_, isProxy := proxyAddresses.Load(address)
+		if !currentEgressPolicy().AllowPrivateNetworks && !isProxy {
+			dialer.Control = egressDialControl
+		}
+		return dialer.DialContext(ctx, network, address)
+	},
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: 1 * time.Second,
+}
+
+// egressTransports holds the copies of the transports other than the default one that verification requests were
+// sent on, by the transport they copy.
+var egressTransports sync.Map
+
+// verificationTransport returns the transport that sends the verification requests of t. An *http.Transport is
+// replaced by a copy that proxies and dials like egressTransport, and keeps its other settings, such as pinned
+// certificates. Other round trippers are used as they are.
+func verificationTransport(t http.RoundTripper) http.RoundTripper {
+	if t == http.DefaultTransport {
+		return egressTransport
+	}
+	transport, ok := t.(*http.Transport)
+	if !ok {
+		return t
+	}
+	if copied, ok := egressTransports.Load(transport); ok {
+		return copied.(*http.Transport)
+	}
+	copied := transport.Clone()
+	copied.Proxy = egressTransport.Proxy
+	copied.DialContext = egressTransport.DialContext
+	actual, _ := egressTransports.LoadOrStore(transport, copied)
+	return actual.(*http.Transport)
+}
+
+// roundTripVerification checks the verification request of the detector against the egress policy before sending it
+// on a transport that enforces the policy when dialing.
+func roundTripVerification(t http.RoundTripper, detector string, req *http.Request) (*http.Response, error) {
+	if err := currentEgressPolicy().checkEgress(req.Context(), detector, req); err != nil {
+		return nil, err
+	}
+	return roundTripRateLimited(verificationTransport(t), req)
+}
@@ -0,0 +1,200 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestBlockedIP(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":              false,
+		"2606:4700:4700::1111": false,
+		"127.0.0.1":            true,
+		"::1":                  true,
+		"10.1.2.3":             true,
+		"172.16.0.1":           true,
+		"192.168.1.1":          true,
+		"169.254.169.254":      true,
+		"fd00:ec2::254":        true,
+		"fe80::1":              true,
+		"100.64.0.1":           true,
+		"0.0.0.0":              true,
+		"::ffff:127.0.0.1":     true,
+		"::ffff:8.8.8.8":       false,
+		"64:ff9b::a9fe:a9fe":   true,
+		"224.0.0.1":            true,
+	}
+	for ip, want := range tests {
+		if got := blockedIP(net.ParseIP(ip)); got != want {
+			t.Errorf("blockedIP(%s) = %t, want %t", ip, got, want)
+		}
+	}
+}
+
+func TestParseAllowedHosts(t *testing.T) {
+	got, err := ParseAllowedHosts([]string{"URI=*.example.com, example.com", "github=api.github.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"uri":    {"*.example.com", "example.com"},
+		"github": {"api.github.com"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, spec := range []string{"uri", "=example.com", "uri= ,"} {
+		if _, err := ParseAllowedHosts([]string{spec}); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"*.example.com", "api.github.com"}
+	for host, want := range map[string]bool{
+		"api.github.com":      true,
+		"www.example.com":     true,
+		"example.com":         false,
+		"badexample.com":      false,
+		"github.com":          false,
+		"api.github.com.evil": false,
+	} {
+		if got := hostAllowed(host, allowed); got != want {
+			t.Errorf("hostAllowed(%s) = %t, want %t", host, got, want)
+		}
+	}
+}
+
+func TestEgressPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	// The proxy answers every request itself, so requests through it don't need to resolve their hosts.
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+	t.Cleanup(func() { SetEgressPolicy(EgressPolicy{}) })
+
+	verification := WithVerification(context.Background(), "URI")
+	tests := []struct {
+		name        string
+		policy      EgressPolicy
+		ctx         context.Context
+		url         string
+		wantBlocked bool
+	}{
+		{name: "not a verification", ctx: context.Background(), url: server.URL},
+		{name: "private address", ctx: verification, url: server.URL, wantBlocked: true},
+		{name: "private address allowed", policy: EgressPolicy{AllowPrivateNetworks: true}, ctx: verification, url: server.URL},
+		{
+			name:        "host not allowed",
+			policy:      EgressPolicy{AllowPrivateNetworks: true, AllowedHosts: map[string][]string{"uri": {"example.com"}}},
+			ctx:         verification,
+			url:         server.URL,
+			wantBlocked: true,
+		},
+		{
+			name:   "host allowed",
+			policy: EgressPolicy{AllowPrivateNetworks: true, AllowedHosts: map[string][]string{"uri": {"127.0.0.1"}}},
+			ctx:    verification,
+			url:    server.URL,
+		},
+		{
+			name:   "allowed hosts of other detector",
+			policy: EgressPolicy{AllowPrivateNetworks: true, AllowedHosts: map[string][]string{"github": {"example.com"}}},
+			ctx:    verification,
+			url:    server.URL,
+		},
+		{
+			name:        "host not allowed for custom detector type",
+			policy:      EgressPolicy{AllowPrivateNetworks: true, AllowedHosts: map[string][]string{"customregex": {"example.com"}}},
+			ctx:         WithVerification(context.Background(), "CustomRegex/internal token"),
+			url:         server.URL,
+			wantBlocked: true,
+		},
+		{
+			name: "host allowed for custom detector",
+			policy: EgressPolicy{AllowPrivateNetworks: true, AllowedHosts: map[string][]string{
+				"customregex":                {"example.com"},
+				"customregex/internal token": {"127.0.0.1"},
+			}},
+			ctx: WithVerification(context.Background(), "CustomRegex/internal token"),
+			url: server.URL,
+		},
+		{name: "private address through proxy", policy: EgressPolicy{Proxy: proxyURL}, ctx: verification, url: "http://localhost/", wantBlocked: true},
+		{name: "proxy", policy: EgressPolicy{Proxy: proxyURL, AllowPrivateNetworks: true}, ctx: verification, url: "http://internal.invalid/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetEgressPolicy(tt.policy)
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := SaneHttpClient().Do(req)
+			if err == nil {
+				res.Body.Close()
+			}
+			if blocked := errors.Is(err, ErrEgressBlocked); blocked != tt.wantBlocked {
+				t.Errorf("got error %v, want blocked %t", err, tt.wantBlocked)
+			}
+			if !tt.wantBlocked && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if tt.policy.Proxy != nil && !tt.wantBlocked {
+				if got := <-proxied; got != tt.url {
+					t.Errorf("proxy got %s, want %s", got, tt.url)
+				}
+			}
+		})
+	}
+}
+
+func TestEgressPolicyCustomTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	proxied := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied <- r.URL.String()
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+	t.Cleanup(func() { SetEgressPolicy(EgressPolicy{}) })
+
+	client := &http.Client{Transport: NewCustomTransport(&http.Transport{})}
+	verification := WithVerification(context.Background(), "URI")
+	do := func(rawURL string) error {
+		req, err := http.NewRequestWithContext(verification, http.MethodGet, rawURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := client.Do(req)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	SetEgressPolicy(EgressPolicy{})
+	if err := do(server.URL); !errors.Is(err, ErrEgressBlocked) {
+		t.Errorf("got error %v, want the private address to be blocked", err)
+	}
+
+	SetEgressPolicy(EgressPolicy{Proxy: proxyURL, AllowPrivateNetworks: true})
+	if err := do("http://internal.invalid/"); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-proxied; got != "http://internal.invalid/" {
+		t.Errorf("proxy got %s, want http://internal.invalid/", got)
+	}
+}
@@ -70,6 +70,38 @@ func FilterFromFiles(includeFilterPath string, excludeFilterPath string, allowRe
 	return filter, nil
 }
 
+// FilterFromPatterns creates a Filter from lists of regular expressions. If there are no include patterns, every
+// object passes the include rules.
+func FilterFromPatterns(include []string, exclude []string) (*Filter, error) {
+	includeRules, err := filterRulesFromPatterns(include)
+	if err != nil {
+		return nil, fmt.Errorf("could not create include rules: %s", err)
+	}
+	excludeRules, err := filterRulesFromPatterns(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("could not create exclude rules: %s", err)
+	}
+	if len(include) == 0 {
+		includeRules = &FilterRuleSet{*regexp.MustCompile("")}
+	}
+	return &Filter{
+		include: includeRules,
+		exclude: excludeRules,
+	}, nil
+}
+
+func filterRulesFromPatterns(patterns []string) (*FilterRuleSet, error) {
+	rules := make(FilterRuleSet, 0, len(patterns))
+	for _, p := range patterns {
+		pattern, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("can not compile regular expression: %s", p)
+		}
+		rules = append(rules, *pattern)
+	}
+	return &rules, nil
+}
+
 // FilterRulesFromFile loads the list of regular expression filter rules in `source` and creates a FilterRuleSet.
 func FilterRulesFromFile(source string, allowRegexp bool) (*FilterRuleSet, error) {
 	rules := FilterRuleSet{}
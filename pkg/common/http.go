@@ -81,7 +81,15 @@ type CustomTransport struct {
 
 func (t *CustomTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.Header.Add("User-Agent", "TruffleHog")
-	return t.T.RoundTrip(req)
+	var res *http.Response
+	var err error
+	if detector, ok := verificationDetector(req.Context()); ok {
+		res, err = roundTripVerification(t.T, detector, req)
+	} else {
+		res, err = roundTripRateLimited(t.T, req)
+	}
+	notifyRequestError(req, res, err)
+	return res, err
 }
 
 func NewCustomTransport(T http.RoundTripper) *CustomTransport {
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxRateLimitRetries is the number of times a request that was rate limited is retried.
+	maxRateLimitRetries = 2
+	// maxRetryAfter caps how long a single Retry-After is waited on before retrying.
+	maxRetryAfter = 30 * time.Second
+	// defaultRetryAfter is used when a rate limited response has no usable Retry-After header.
+	defaultRetryAfter = time.Second
+)
+
+type rateLimitNotifierKey struct{}
+
+// RateLimitNotifier is called with how long the provider asked to wait when a request is rate limited.
+type RateLimitNotifier func(retryAfter time.Duration)
+
+// WithRateLimitNotifier returns a context that notifies the given function when a request made with it through a
+// CustomTransport is rate limited.
+func WithRateLimitNotifier(ctx context.Context, notify RateLimitNotifier) context.Context {
+	return context.WithValue(ctx, rateLimitNotifierKey{}, notify)
+}
+
+func rateLimitNotifierFromContext(ctx context.Context) RateLimitNotifier {
+	notify, _ := ctx.Value(rateLimitNotifierKey{}).(RateLimitNotifier)
+	return notify
+}
+
+type requestLimiterKey struct{}
+
+// RequestLimiter is called before every request made with a context returned by WithRequestLimiter. The request isn't
+// sent if it returns an error.
+type RequestLimiter func(ctx context.Context) error
+
+// WithRequestLimiter returns a context whose requests made through a CustomTransport wait for the limiter before they
+// are sent, including the retries of rate limited requests.
+func WithRequestLimiter(ctx context.Context, limit RequestLimiter) context.Context {
+	return context.WithValue(ctx, requestLimiterKey{}, limit)
+}
+
+// waitForLimiter waits for the limiter of the request's context, if it has one.
+func waitForLimiter(req *http.Request) error {
+	limit, _ := req.Context().Value(requestLimiterKey{}).(RequestLimiter)
+	if limit == nil {
+		return nil
+	}
+	return limit(req.Context())
+}
+
+// RetryAfter returns how long a 429 or 503 response asks the client to wait, and false if it is not rate limited.
+func RetryAfter(res *http.Response) (time.Duration, bool) {
+	if res == nil || (res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+	header := res.Header.Get("Retry-After")
+	if header == "" {
+		if res.StatusCode == http.StatusServiceUnavailable {
+			return 0, false
+		}
+		return defaultRetryAfter, true
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		d := time.Until(when)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return defaultRetryAfter, true
+}
+
+// roundTripRateLimited sends the request and, if the response asks to slow down, notifies the context and retries
+// the request after waiting for as long as the provider asked.
+func roundTripRateLimited(t http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if err := waitForLimiter(req); err != nil {
+		return nil, err
+	}
+	res, err := t.RoundTrip(req)
+	for attempt := 0; err == nil && attempt < maxRateLimitRetries; attempt++ {
+		retryAfter, limited := RetryAfter(res)
+		if !limited {
+			break
+		}
+		ctx := req.Context()
+		if notify := rateLimitNotifierFromContext(ctx); notify != nil {
+			notify(retryAfter)
+		}
+		// Requests with a body that can't be replayed can't be retried.
+		if req.Body != nil && req.GetBody == nil {
+			break
+		}
+		if retryAfter > maxRetryAfter {
+			break
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < retryAfter {
+			break
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, nil
+		case <-timer.C:
+		}
+
+		retry := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				break
+			}
+			retry.Body = body
+		}
+		res.Body.Close()
+		if err := waitForLimiter(retry); err != nil {
+			return nil, err
+		}
+		res, err = t.RoundTrip(retry)
+	}
+	return res, err
+}
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		header      string
+		want        time.Duration
+		wantLimited bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "429 seconds", status: http.StatusTooManyRequests, header: "3", want: 3 * time.Second, wantLimited: true},
+		{name: "429 no header", status: http.StatusTooManyRequests, want: defaultRetryAfter, wantLimited: true},
+		{name: "429 past date", status: http.StatusTooManyRequests, header: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, wantLimited: true},
+		{name: "503 seconds", status: http.StatusServiceUnavailable, header: "1", want: time.Second, wantLimited: true},
+		{name: "503 no header", status: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.header != "" {
+				res.Header.Set("Retry-After", tt.header)
+			}
+			got, limited := RetryAfter(res)
+			if got != tt.want || limited != tt.wantLimited {
+				t.Errorf("RetryAfter() = %s, %t, want %s, %t", got, limited, tt.want, tt.wantLimited)
+			}
+		})
+	}
+}
+
+func TestCustomTransportRateLimit(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var notified int32
+	ctx := WithRateLimitNotifier(context.Background(), func(time.Duration) {
+		atomic.AddInt32(&notified, 1)
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := SaneHttpClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want %d", res.StatusCode, http.StatusOK)
+	}
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+	if notified != 1 {
+		t.Errorf("got %d rate limit notifications, want 1", notified)
+	}
+}
+
+func TestCustomTransportRequestLimiter(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var waits int32
+	limitErr := errors.New("limited")
+	ctx := WithRequestLimiter(context.Background(), func(context.Context) error {
+		if atomic.AddInt32(&waits, 1) > 1 {
+			return limitErr
+		}
+		return nil
+	})
+	for i, wantErr := range []bool{false, true} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := SaneHttpClient().Do(req)
+		if (err != nil) != wantErr {
+			t.Fatalf("request %d: got error %v, want error %t", i, err, wantErr)
+		}
+		if err == nil {
+			res.Body.Close()
+		}
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests, want 1", requests)
+	}
+}
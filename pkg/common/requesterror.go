@@ -0,0 +1,35 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type requestErrorNotifierKey struct{}
+
+// RequestErrorNotifier is called with the error of a request that failed without an answer from the provider, such as
+// a timeout, a connection error, or a server error response.
+type RequestErrorNotifier func(err error)
+
+// WithRequestErrorNotifier returns a context that notifies the given function when a request made with it through a
+// CustomTransport fails. Detectors often treat a failed request like a secret that isn't valid, so the engine uses it
+// to tell the two apart.
+func WithRequestErrorNotifier(ctx context.Context, notify RequestErrorNotifier) context.Context {
+	return context.WithValue(ctx, requestErrorNotifierKey{}, notify)
+}
+
+// notifyRequestError notifies the context of the request if the request failed or the provider responded with a
+// server error, or was still rate limiting it after the retries.
+func notifyRequestError(req *http.Request, res *http.Response, err error) {
+	notify, _ := req.Context().Value(requestErrorNotifierKey{}).(RequestErrorNotifier)
+	if notify == nil {
+		return
+	}
+	switch {
+	case err != nil:
+		notify(err)
+	case res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusTooManyRequests:
+		notify(fmt.Errorf("%s responded with %s", req.URL.Host, res.Status))
+	}
+}
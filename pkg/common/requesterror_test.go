@@ -0,0 +1,46 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCustomTransportRequestErrorNotifier(t *testing.T) {
+	var status int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		status     int32
+		url        string
+		wantNotify bool
+	}{
+		{name: "ok", status: http.StatusOK, url: server.URL},
+		{name: "unauthorized", status: http.StatusUnauthorized, url: server.URL},
+		{name: "server error", status: http.StatusInternalServerError, url: server.URL, wantNotify: true},
+		{name: "connection error", url: "http://127.0.0.1:0/", wantNotify: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&status, tt.status)
+			var notified error
+			ctx := WithRequestErrorNotifier(context.Background(), func(err error) { notified = err })
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res, err := SaneHttpClient().Do(req); err == nil {
+				res.Body.Close()
+			}
+			if (notified != nil) != tt.wantNotify {
+				t.Errorf("got notified error %v, want notification %t", notified, tt.wantNotify)
+			}
+		})
+	}
+}
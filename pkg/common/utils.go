@@ -1,5 +1,12 @@
 package common
 
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/go-errors/errors"
+)
+
 func AddStringSliceItem(item string, slice *[]string) {
 	for _, i := range *slice {
 		if i == item {
@@ -17,3 +24,24 @@ func RemoveStringSliceItem(item string, slice *[]string) {
 		}
 	}
 }
+
+// WriteFileAtomic writes data to the file at path. It writes to a temporary file first so an interrupted write
+// doesn't corrupt an existing file.
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.WrapPrefix(err, "could not create file", 0)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.WrapPrefix(err, "could not write file", 0)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.WrapPrefix(err, "could not write file", 0)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.WrapPrefix(err, "could not save file", 0)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+// Package config reads the configuration of scans that run several sources at once.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-errors/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/custom_detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+// Config describes a scan. An example:
+//
+//	detectors:
+//	  exclude: [generic]
+//	filters:
+//	  exclude_paths: ['^vendor/']
+//	  exclude_paths_file: .trufflehogignore
+//	output:
+//	  format: json
+//	  only_verified: true
+//	  sinks: [plain, json=results.json]
+//	verification:
+//	  skip_source_types: [s3]
+//	sources:
+//	  - type: github
+//	    name: our orgs
+//	    connection:
+//	      token: ghp_...
+//	      organizations: [trufflesecurity, another-org]
+//	  - type: s3
+//	    verify: false
+//	    connection:
+//	      cloud_environment: {}
+//	      buckets: [backups]
+//	  - type: filesystem
+//	    connection:
+//	      directories: [/srv/config]
+//
+// The connection of a source is the sourcespb message of its type, written like its JSON form.
+type Config struct {
+	Sources   []Source  `json:"-"`
+	Detectors Detectors `json:"detectors"`
+	Filters   Filters   `json:"filters"`
+	Output    Output    `json:"output"`
+	// Verification restricts which sources the secrets are verified in, on top of the verify setting of each source.
+	Verification Verification `json:"verification"`
+	// CustomDetectors declares custom regex detectors, like the file of the --rules flag.
+	CustomDetectors []custom_detectors.DetectorConfig `json:"custom_detectors"`
+}
+
+// Source is a source to scan.
+type Source struct {
+	// Type is the type of the source, such as github or s3.
+	Type sourcespb.SourceType
+	// Name tells the sources apart in the results. It defaults to the type and position of the source.
+	Name string
+	// Verify verifies the secrets found in the source. It defaults to true.
+	Verify bool
+	// Connection is the sourcespb message that configures the source, such as *sourcespb.GitHub.
+	Connection proto.Message
+}
+
+// Detectors selects the detectors that run by name or by tag, written as "tag:NAME", like the --include-detectors and --exclude-detectors flags.
+type Detectors struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+// Filters selects the files that are scanned with regular expressions matched against their paths.
+type Filters struct {
+	IncludePaths []string `json:"include_paths"`
+	ExcludePaths []string `json:"exclude_paths"`
+	// IncludePathsFile and ExcludePathsFile are files with newline separated regular expressions, like the files of
+	// the --include-paths and --exclude-paths flags of the git command. They add to IncludePaths and ExcludePaths.
+	IncludePathsFile string `json:"include_paths_file"`
+	ExcludePathsFile string `json:"exclude_paths_file"`
+}
+
+// Verification restricts verification by the type and name of the sources, like the --verify-only-source-types,
+// --no-verify-source-types and --no-verify-source flags.
+type Verification struct {
+	OnlySourceTypes []string `json:"only_source_types"`
+	SkipSourceTypes []string `json:"skip_source_types"`
+	SkipSources     []string `json:"skip_sources"`
+}
+
+// Output configures how results are reported.
+type Output struct {
+	// Format is plain, json or json-legacy.
+	Format       string `json:"format"`
+	OnlyVerified bool   `json:"only_verified"`
+	// IncludeUnknown also outputs results that couldn't be verified because of an error when OnlyVerified is set.
+	IncludeUnknown   bool `json:"include_unknown"`
+	Dedupe           bool `json:"dedupe"`
+	DedupeByLocation bool `json:"dedupe_by_location"`
+	// Sinks write the results instead of the format, like the --sink flag, such as "plain" or "json=results.json".
+	Sinks []string `json:"sinks"`
+}
+
+// Output formats.
+const (
+	FormatPlain      = "plain"
+	FormatJSON       = "json"
+	FormatJSONLegacy = "json-legacy"
+)
+
+// sourceTypes maps the names of the source types that can be configured to their connection messages.
+var sourceTypes = map[string]struct {
+	sourceType sourcespb.SourceType
+	connection func() proto.Message
+}{
+	"filesystem": {sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM, func() proto.Message { return &sourcespb.Filesystem{} }},
+	"git":        {sourcespb.SourceType_SOURCE_TYPE_GIT, func() proto.Message { return &sourcespb.Git{} }},
+	"github":     {sourcespb.SourceType_SOURCE_TYPE_GITHUB, func() proto.Message { return &sourcespb.GitHub{} }},
+	"gitlab":     {sourcespb.SourceType_SOURCE_TYPE_GITLAB, func() proto.Message { return &sourcespb.GitLab{} }},
+	"s3":         {sourcespb.SourceType_SOURCE_TYPE_S3, func() proto.Message { return &sourcespb.S3{} }},
+}
+
+// rawSource is a source as it is written in the file, before its connection is parsed.
+type rawSource struct {
+	Type       string          `json:"type"`
+	Name       string          `json:"name"`
+	Verify     *bool           `json:"verify"`
+	Connection json.RawMessage `json:"connection"`
+}
+
+// Read reads the configuration file at path.
+func Read(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not read config", 0)
+	}
+	return Parse(data)
+}
+
+// Parse parses a YAML or JSON configuration.
+func Parse(data []byte) (*Config, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not parse config", 0)
+	}
+	var file struct {
+		Config
+		Sources []rawSource `json:"sources"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, errors.WrapPrefix(err, "could not parse config", 0)
+	}
+
+	c := file.Config
+	names := map[string]bool{}
+	for i, raw := range file.Sources {
+		source, err := parseSource(i, raw)
+		if err != nil {
+			return nil, err
+		}
+		if names[source.Name] {
+			return nil, errors.Errorf("source %d: there is more than one source named %q", i+1, source.Name)
+		}
+		names[source.Name] = true
+		c.Sources = append(c.Sources, source)
+	}
+	if len(c.Sources) == 0 {
+		return nil, errors.New("config has no sources")
+	}
+
+	switch c.Output.Format {
+	case "":
+		c.Output.Format = FormatPlain
+	case FormatPlain, FormatJSON, FormatJSONLegacy:
+	default:
+		return nil, errors.Errorf("unknown output format %q, must be %s, %s or %s", c.Output.Format, FormatPlain, FormatJSON, FormatJSONLegacy)
+	}
+	if _, err := c.Filters.Filter(); err != nil {
+		return nil, err
+	}
+	if _, err := custom_detectors.New(c.CustomDetectors...); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func parseSource(i int, raw rawSource) (Source, error) {
+	sourceType, ok := sourceTypes[strings.ToLower(raw.Type)]
+	if !ok {
+		return Source{}, errors.Errorf("source %d: unknown type %q, must be one of %s", i+1, raw.Type, strings.Join(SourceTypeNames(), ", "))
+	}
+	source := Source{
+		Type:       sourceType.sourceType,
+		Name:       raw.Name,
+		Verify:     raw.Verify == nil || *raw.Verify,
+		Connection: sourceType.connection(),
+	}
+	if source.Name == "" {
+		source.Name = fmt.Sprintf("trufflehog - %s %d", strings.ToLower(raw.Type), i+1)
+	}
+	if len(raw.Connection) > 0 {
+		if err := protojson.Unmarshal(raw.Connection, source.Connection); err != nil {
+			return Source{}, errors.WrapPrefix(err, fmt.Sprintf("source %d: invalid %s connection", i+1, raw.Type), 0)
+		}
+	}
+	if v, ok := source.Connection.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return Source{}, errors.WrapPrefix(err, fmt.Sprintf("source %d: invalid %s connection", i+1, raw.Type), 0)
+		}
+	}
+	return source, nil
+}
+
+// SourceTypeNames returns the names of the source types that can be configured.
+func SourceTypeNames() []string {
+	names := make([]string, 0, len(sourceTypes))
+	for name := range sourceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Filter returns the filter of the configured paths. Every path passes if there are no include paths.
+func (f Filters) Filter() (*common.Filter, error) {
+	include, err := patternsFromFile(f.IncludePaths, f.IncludePathsFile)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := patternsFromFile(f.ExcludePaths, f.ExcludePathsFile)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := common.FilterFromPatterns(include, exclude)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "invalid filters", 0)
+	}
+	return filter, nil
+}
+
+// patternsFromFile returns the patterns followed by the ones in the file at path, if there is one.
+func patternsFromFile(patterns []string, path string) ([]string, error) {
+	if path == "" {
+		return patterns, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, errors.WrapPrefix(err, "invalid filters", 0)
+	}
+	rules, err := common.FilterRulesFromFile(path, true)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, fmt.Sprintf("invalid filters in %s", path), 0)
+	}
+	all := append([]string(nil), patterns...)
+	for _, rule := range *rules {
+		all = append(all, rule.String())
+	}
+	return all, nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/credentialspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+)
+
+func TestParse(t *testing.T) {
+	excludeFile := filepath.Join(t.TempDir(), "exclude.txt")
+	if err := os.WriteFile(excludeFile, []byte("# generated code\n_gen\\.go$\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Parse([]byte(fmt.Sprintf(`
+detectors:
+  include: [aws, github]
+  exclude: [generic]
+filters:
+  include_paths: ['\.go$']
+  exclude_paths: ['^vendor/']
+  exclude_paths_file: %q
+output:
+  format: json
+  only_verified: true
+  sinks: [plain, json=results.json]
+verification:
+  only_source_types: [github, filesystem]
+  skip_sources: [orgs]
+sources:
+  - type: github
+    name: orgs
+    connection:
+      token: secret
+      organizations: [trufflesecurity, another-org]
+      includeForks: true
+  - type: s3
+    verify: false
+    connection:
+      access_key: {key: id, secret: secret}
+      buckets: [backups]
+  - type: filesystem
+    connection:
+      directories: [/srv/config]
+`, excludeFile)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSources := []Source{
+		{
+			Type:   sourcespb.SourceType_SOURCE_TYPE_GITHUB,
+			Name:   "orgs",
+			Verify: true,
+			Connection: &sourcespb.GitHub{
+				Credential:    &sourcespb.GitHub_Token{Token: "secret"},
+				Organizations: []string{"trufflesecurity", "another-org"},
+				IncludeForks:  true,
+			},
+		},
+		{
+			Type: sourcespb.SourceType_SOURCE_TYPE_S3,
+			Name: "trufflehog - s3 2",
+			Connection: &sourcespb.S3{
+				Credential: &sourcespb.S3_AccessKey{AccessKey: &credentialspb.KeySecret{Key: "id", Secret: "secret"}},
+				Buckets:    []string{"backups"},
+			},
+		},
+		{
+			Type:       sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM,
+			Name:       "trufflehog - filesystem 3",
+			Verify:     true,
+			Connection: &sourcespb.Filesystem{Directories: []string{"/srv/config"}},
+		},
+	}
+	if len(c.Sources) != len(wantSources) {
+		t.Fatalf("got %d sources, want %d", len(c.Sources), len(wantSources))
+	}
+	for i, want := range wantSources {
+		got := c.Sources[i]
+		if got.Type != want.Type || got.Name != want.Name || got.Verify != want.Verify {
+			t.Errorf("source %d: got %s %q verify %v, want %s %q verify %v", i, got.Type, got.Name, got.Verify, want.Type, want.Name, want.Verify)
+		}
+		if !proto.Equal(got.Connection, want.Connection) {
+			t.Errorf("source %d: got connection %v, want %v", i, got.Connection, want.Connection)
+		}
+	}
+
+	wantDetectors := Detectors{Include: []string{"aws", "github"}, Exclude: []string{"generic"}}
+	if diff := pretty.Compare(c.Detectors, wantDetectors); diff != "" {
+		t.Errorf("detectors (-got +want)\n%s", diff)
+	}
+	wantOutput := Output{Format: FormatJSON, OnlyVerified: true, Sinks: []string{"plain", "json=results.json"}}
+	if diff := pretty.Compare(c.Output, wantOutput); diff != "" {
+		t.Errorf("output (-got +want)\n%s", diff)
+	}
+	wantVerification := Verification{OnlySourceTypes: []string{"github", "filesystem"}, SkipSources: []string{"orgs"}}
+	if diff := pretty.Compare(c.Verification, wantVerification); diff != "" {
+		t.Errorf("verification (-got +want)\n%s", diff)
+	}
+
+	filter, err := c.Filters.Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{"main.go": true, "vendor/lib.go": false, "README.md": false, "api_gen.go": false} {
+		if got := filter.Pass(path); got != want {
+			t.Errorf("filter.Pass(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:    "no sources",
+			config:  "output: {format: json}",
+			wantErr: "no sources",
+		},
+		{
+			name:    "unknown source type",
+			config:  "sources: [{type: ftp}]",
+			wantErr: `unknown type "ftp"`,
+		},
+		{
+			name:    "unknown connection field",
+			config:  "sources: [{type: filesystem, connection: {paths: [.]}}]",
+			wantErr: "invalid filesystem connection",
+		},
+		{
+			name:    "invalid connection",
+			config:  "sources: [{type: github, connection: {endpoint: '%zz'}}]",
+			wantErr: "invalid github connection",
+		},
+		{
+			name:    "duplicate names",
+			config:  "sources: [{type: filesystem, name: a}, {type: s3, name: a}]",
+			wantErr: `more than one source named "a"`,
+		},
+		{
+			name:    "unknown setting",
+			config:  "sources: [{type: filesystem}]\nverbose: true",
+			wantErr: "unknown field",
+		},
+		{
+			name:    "unknown format",
+			config:  "sources: [{type: filesystem}]\noutput: {format: xml}",
+			wantErr: `unknown output format "xml"`,
+		},
+		{
+			name:    "invalid filter",
+			config:  "sources: [{type: filesystem}]\nfilters: {exclude_paths: ['(']}",
+			wantErr: "invalid filters",
+		},
+		{
+			name:    "missing filter file",
+			config:  "sources: [{type: filesystem}]\nfilters: {include_paths_file: does-not-exist.txt}",
+			wantErr: "invalid filters",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,409 @@
+// Package custom_detectors loads detectors that users declare in a configuration file, for secrets that the built-in
+// detectors don't cover.
+package custom_detectors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-errors/errors"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// Config declares custom detectors. An example:
+//
+//	detectors:
+//	  - name: internal token
+//	    keywords: [itk_]
+//	    regex: 'itk_(?P<id>[a-z0-9]{8})_(?P<secret>[a-z0-9]{32})'
+//	    exclude_regexes: ['itk_0{8}_']
+//	    verify:
+//	      method: GET
+//	      url: 'https://auth.example.com/tokens/{{ .id }}'
+//	      headers:
+//	        Authorization: 'Bearer {{ .secret }}'
+//	      success:
+//	        status_codes: [200]
+//	        body_contains: ['"active":true']
+type Config struct {
+	Detectors []DetectorConfig `json:"detectors"`
+}
+
+// DetectorConfig declares a detector that finds the matches of a regular expression.
+type DetectorConfig struct {
+	// Name is reported in the extra data of the results.
+	Name string `json:"name"`
+	// Keywords are used to pre-filter the data, like the keywords of the built-in detectors. At least one is required.
+	Keywords []string `json:"keywords"`
+	// Regex finds the secrets. Its named capture groups can be used in the verification request.
+	Regex string `json:"regex"`
+	// ExcludeRegexes ignore the matches of Regex that they match, such as placeholders.
+	ExcludeRegexes []string `json:"exclude_regexes"`
+	// Verify optionally verifies the matches with an HTTP request.
+	Verify *VerifyConfig `json:"verify"`
+}
+
+// VerifyConfig is an HTTP request that verifies a match. The URL, the header values and the body are Go templates
+// executed with the named capture groups of the match, and the whole match as .match. The values are escaped for the
+// part of the URL they are in, with url.PathEscape before the query and url.QueryEscape after, so that a match can't
+// change the path or add query parameters.
+type VerifyConfig struct {
+	// Method defaults to GET.
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	Success SuccessConfig     `json:"success"`
+}
+
+// SuccessConfig decides whether a verification response means the secret is valid. Every criterion that is set must
+// be met. Without any, the status code has to be 2xx.
+type SuccessConfig struct {
+	StatusCodes []int `json:"status_codes"`
+	// BodyContains are strings that must all be in the body.
+	BodyContains []string `json:"body_contains"`
+	// BodyRegex must match the body.
+	BodyRegex string `json:"body_regex"`
+}
+
+// Regex is a detector declared by a DetectorConfig.
+type Regex struct {
+	name     string
+	keywords []string
+	regex    *regexp.Regexp
+	excludes []*regexp.Regexp
+	verifier *verifier
+	client   *http.Client
+}
+
+// Ensure the Regex detector satisfies the interface at compile time.
+var _ detectors.Detector = (*Regex)(nil)
+
+type verifier struct {
+	method       string
+	url          *template.Template
+	headers      map[string]*template.Template
+	body         *template.Template
+	statusCodes  []int
+	bodyContains []string
+	bodyRegex    *regexp.Regexp
+}
+
+// maxBodySize is how much of a verification response is read to check the success criteria.
+const maxBodySize = 1 << 20
+
+// Read reads the custom detectors declared in the YAML or JSON file at path.
+func Read(path string) ([]detectors.Detector, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not read custom detectors", 0)
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not parse custom detectors", 0)
+	}
+	var c Config
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&c); err != nil {
+		return nil, errors.WrapPrefix(err, "could not parse custom detectors", 0)
+	}
+	return New(c.Detectors...)
+}
+
+// New creates the detectors declared by the configs.
+func New(configs ...DetectorConfig) ([]detectors.Detector, error) {
+	names := map[string]bool{}
+	ds := make([]detectors.Detector, 0, len(configs))
+	for _, c := range configs {
+		d, err := NewRegex(c)
+		if err != nil {
+			return nil, err
+		}
+		if names[c.Name] {
+			return nil, errors.Errorf("there is more than one custom detector named %q", c.Name)
+		}
+		names[c.Name] = true
+		ds = append(ds, d)
+	}
+	return ds, nil
+}
+
+// NewRegex creates the detector declared by the config.
+func NewRegex(c DetectorConfig) (*Regex, error) {
+	if c.Name == "" {
+		return nil, errors.New("custom detector has no name")
+	}
+	prefix := fmt.Sprintf("custom detector %q", c.Name)
+	if len(c.Keywords) == 0 {
+		return nil, errors.Errorf("%s has no keywords", prefix)
+	}
+	for _, kw := range c.Keywords {
+		if kw == "" {
+			return nil, errors.Errorf("%s has an empty keyword", prefix)
+		}
+	}
+	if c.Regex == "" {
+		return nil, errors.Errorf("%s has no regex", prefix)
+	}
+	regex, err := regexp.Compile(c.Regex)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, prefix+" has an invalid regex", 0)
+	}
+
+	d := &Regex{
+		name:     c.Name,
+		keywords: c.Keywords,
+		regex:    regex,
+		client:   common.SaneHttpClient(),
+	}
+	for _, exclude := range c.ExcludeRegexes {
+		excludeRegex, err := regexp.Compile(exclude)
+		if err != nil {
+			return nil, errors.WrapPrefix(err, prefix+" has an invalid exclude regex", 0)
+		}
+		d.excludes = append(d.excludes, excludeRegex)
+	}
+	if c.Verify != nil {
+		d.verifier, err = newVerifier(*c.Verify, regex)
+		if err != nil {
+			return nil, errors.WrapPrefix(err, prefix+" has an invalid verification", 0)
+		}
+	}
+	return d, nil
+}
+
+func newVerifier(c VerifyConfig, regex *regexp.Regexp) (*verifier, error) {
+	if c.URL == "" {
+		return nil, errors.New("no url")
+	}
+	v := &verifier{
+		method:       strings.ToUpper(c.Method),
+		headers:      map[string]*template.Template{},
+		statusCodes:  c.Success.StatusCodes,
+		bodyContains: c.Success.BodyContains,
+	}
+	if v.method == "" {
+		v.method = http.MethodGet
+	}
+
+	// Executing the templates with every capture group catches references to groups that don't exist.
+	groups := map[string]string{"match": ""}
+	for _, name := range regex.SubexpNames() {
+		if name != "" {
+			groups[name] = ""
+		}
+	}
+	parse := func(name, text string) (*template.Template, error) {
+		t, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.Execute(io.Discard, groups); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+
+	var err error
+	if v.url, err = parse("url", c.URL); err != nil {
+		return nil, err
+	}
+	if v.body, err = parse("body", c.Body); err != nil {
+		return nil, err
+	}
+	for key, value := range c.Headers {
+		if v.headers[key], err = parse(key, value); err != nil {
+			return nil, err
+		}
+	}
+	if c.Success.BodyRegex != "" {
+		if v.bodyRegex, err = regexp.Compile(c.Success.BodyRegex); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// FromData will find and optionally verify secrets in a given set of bytes.
+func (d *Regex) FromData(ctx context.Context, verify bool, data []byte) (results []detectors.Result, err error) {
+	for _, match := range d.regex.FindAllSubmatchIndex(data, -1) {
+		raw := data[match[0]:match[1]]
+		if d.excluded(raw) {
+			continue
+		}
+
+		s := detectors.Result{
+			DetectorType: detectorspb.DetectorType_CustomRegex,
+			Raw:          raw,
+			ExtraData:    map[string]string{"name": d.name},
+		}
+		if verify && d.verifier != nil {
+			groups := d.groups(data, match)
+			verified, err := d.verifier.verify(ctx, d.client, groups)
+			if err != nil {
+				secrets := make([]string, 0, len(groups))
+				for _, value := range groups {
+					secrets = append(secrets, value)
+				}
+				s.SetVerificationError(err, secrets...)
+			}
+			s.Verified = verified
+		}
+		results = append(results, s)
+	}
+	return results, nil
+}
+
+func (d *Regex) excluded(match []byte) bool {
+	for _, exclude := range d.excludes {
+		if exclude.Match(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// groups returns the named capture groups of the match, and the whole match as "match".
+func (d *Regex) groups(data []byte, match []int) map[string]string {
+	groups := map[string]string{"match": string(data[match[0]:match[1]])}
+	for i, name := range d.regex.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		groups[name] = ""
+		if match[2*i] >= 0 {
+			groups[name] = string(data[match[2*i]:match[2*i+1]])
+		}
+	}
+	return groups
+}
+
+// Keywords are used for efficiently pre-filtering chunks.
+func (d *Regex) Keywords() []string {
+	return d.keywords
+}
+
+// Type returns the DetectorType of the results the detector finds. Every custom detector has the same type, and the
+// name of the detector is in the extra data of its results.
+func (d *Regex) Type() detectorspb.DetectorType {
+	return detectorspb.DetectorType_CustomRegex
+}
+
+// Name returns the name of the detector.
+func (d *Regex) Name() string {
+	return d.name
+}
+
+func (v *verifier) verify(ctx context.Context, client *http.Client, groups map[string]string) (bool, error) {
+	reqURL, err := executeURL(v.url, groups)
+	if err != nil {
+		return false, err
+	}
+	body, err := execute(v.body, groups)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, v.method, reqURL, strings.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for key, t := range v.headers {
+		value, err := execute(t, groups)
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set(key, value)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(io.LimitReader(res.Body, maxBodySize))
+	if err != nil {
+		return false, err
+	}
+	return v.success(res.StatusCode, resBody), nil
+}
+
+func (v *verifier) success(statusCode int, body []byte) bool {
+	if len(v.statusCodes) == 0 && len(v.bodyContains) == 0 && v.bodyRegex == nil {
+		return statusCode >= 200 && statusCode < 300
+	}
+	if len(v.statusCodes) > 0 {
+		ok := false
+		for _, code := range v.statusCodes {
+			ok = ok || code == statusCode
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, s := range v.bodyContains {
+		if !bytes.Contains(body, []byte(s)) {
+			return false
+		}
+	}
+	return v.bodyRegex == nil || v.bodyRegex.Match(body)
+}
+
+func execute(t *template.Template, groups map[string]string) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, groups); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// placeholderRegex matches the placeholders that executeURL substitutes for the values of the groups.
+var placeholderRegex = regexp.MustCompile("\x00([0-9]+)\x00")
+
+// executeURL executes the URL template with the values of the groups escaped for the part of the URL they end up in.
+// The template is first executed with placeholders, since where a value ends up is only known afterwards.
+func executeURL(t *template.Template, groups map[string]string) (string, error) {
+	placeholders := make(map[string]string, len(groups))
+	values := make([]string, 0, len(groups))
+	for name, value := range groups {
+		placeholders[name] = "\x00" + strconv.Itoa(len(values)) + "\x00"
+		values = append(values, value)
+	}
+	withPlaceholders, err := execute(t, placeholders)
+	if err != nil {
+		return "", err
+	}
+
+	query := strings.IndexAny(withPlaceholders, "?#")
+	if query < 0 {
+		query = len(withPlaceholders)
+	}
+	var b strings.Builder
+	last := 0
+	for _, match := range placeholderRegex.FindAllStringSubmatchIndex(withPlaceholders, -1) {
+		b.WriteString(withPlaceholders[last:match[0]])
+		i, _ := strconv.Atoi(withPlaceholders[match[2]:match[3]])
+		if match[0] < query {
+			b.WriteString(url.PathEscape(values[i]))
+		} else {
+			b.WriteString(url.QueryEscape(values[i]))
+		}
+		last = match[1]
+	}
+	b.WriteString(withPlaceholders[last:])
+	return b.String(), nil
+}
@@ -0,0 +1,254 @@
+package custom_detectors
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+func TestRegex_FromData(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.URL.Path != "/tokens/abcd1234" || string(body) != "secret=validsecret" ||
+			r.Header.Get("Authorization") != "Bearer itk_abcd1234_validsecret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"active":true}`))
+	}))
+	defer server.Close()
+
+	d, err := NewRegex(DetectorConfig{
+		Name:           "internal token",
+		Keywords:       []string{"itk_"},
+		Regex:          `itk_(?P<id>[a-z0-9]{8})_(?P<secret>[a-z]+)`,
+		ExcludeRegexes: []string{`_example$`},
+		Verify: &VerifyConfig{
+			Method:  "post",
+			URL:     server.URL + "/tokens/{{ .id }}",
+			Headers: map[string]string{"Authorization": "Bearer {{ .match }}"},
+			Body:    "secret={{ .secret }}",
+			Success: SuccessConfig{StatusCodes: []int{200}, BodyContains: []string{`"active":true`}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("a = itk_abcd1234_validsecret\nb = itk_abcd1234_revoked\nc = itk_00000000_example")
+	tests := []struct {
+		name   string
+		verify bool
+		want   []detectors.Result
+	}{
+		{
+			name:   "verified",
+			verify: true,
+			want: []detectors.Result{
+				{Raw: []byte("itk_abcd1234_validsecret"), Verified: true},
+				{Raw: []byte("itk_abcd1234_revoked"), Verified: false},
+			},
+		},
+		{
+			name:   "not verified",
+			verify: false,
+			want: []detectors.Result{
+				{Raw: []byte("itk_abcd1234_validsecret")},
+				{Raw: []byte("itk_abcd1234_revoked")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.FromData(context.Background(), tt.verify, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d results, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if string(got[i].Raw) != string(want.Raw) || got[i].Verified != want.Verified {
+					t.Errorf("result %d: got %q verified %v, want %q verified %v", i, got[i].Raw, got[i].Verified, want.Raw, want.Verified)
+				}
+				if got[i].DetectorType != detectorspb.DetectorType_CustomRegex || got[i].ExtraData["name"] != "internal token" {
+					t.Errorf("result %d: got type %s and extra data %v", i, got[i].DetectorType, got[i].ExtraData)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifierSuccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		success    SuccessConfig
+		statusCode int
+		body       string
+		want       bool
+	}{
+		{name: "default 2xx", statusCode: 204, want: true},
+		{name: "default not 2xx", statusCode: 401, want: false},
+		{name: "status code", success: SuccessConfig{StatusCodes: []int{403}}, statusCode: 403, want: true},
+		{name: "wrong status code", success: SuccessConfig{StatusCodes: []int{200}}, statusCode: 403, want: false},
+		{name: "body contains", success: SuccessConfig{BodyContains: []string{"ok", "user"}}, statusCode: 500, body: "ok user", want: true},
+		{name: "body misses", success: SuccessConfig{BodyContains: []string{"ok", "user"}}, statusCode: 200, body: "ok", want: false},
+		{name: "body regex", success: SuccessConfig{BodyRegex: `"scopes":\s*\[`}, statusCode: 200, body: `{"scopes": []}`, want: true},
+		{name: "body regex misses", success: SuccessConfig{StatusCodes: []int{200}, BodyRegex: `"id"`}, statusCode: 200, body: `{}`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := newVerifier(VerifyConfig{URL: "https://example.com", Success: tt.success}, regexp.MustCompile("x"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := v.success(tt.statusCode, []byte(tt.body)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		groups map[string]string
+		want   string
+	}{
+		{
+			name:   "path",
+			url:    "https://example.com/tokens/{{ .id }}",
+			groups: map[string]string{"id": "../admin?x=1"},
+			want:   "https://example.com/tokens/..%2Fadmin%3Fx=1",
+		},
+		{
+			name:   "query",
+			url:    "https://example.com/tokens/{{ .id }}?secret={{ .secret }}",
+			groups: map[string]string{"id": "abc", "secret": "a&admin=true #"},
+			want:   "https://example.com/tokens/abc?secret=a%26admin%3Dtrue+%23",
+		},
+		{
+			name:   "fragment",
+			url:    "https://example.com/#{{ .match }}",
+			groups: map[string]string{"match": "a/b"},
+			want:   "https://example.com/#a%2Fb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := executeURL(template.Must(template.New("url").Parse(tt.url)), tt.groups)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRegexErrors(t *testing.T) {
+	valid := func() DetectorConfig {
+		return DetectorConfig{Name: "token", Keywords: []string{"tok_"}, Regex: `tok_(?P<secret>\w+)`}
+	}
+	tests := []struct {
+		name    string
+		modify  func(*DetectorConfig)
+		wantErr string
+	}{
+		{name: "no name", modify: func(c *DetectorConfig) { c.Name = "" }, wantErr: "no name"},
+		{name: "no keywords", modify: func(c *DetectorConfig) { c.Keywords = nil }, wantErr: "no keywords"},
+		{name: "empty keyword", modify: func(c *DetectorConfig) { c.Keywords = []string{""} }, wantErr: "empty keyword"},
+		{name: "no regex", modify: func(c *DetectorConfig) { c.Regex = "" }, wantErr: "no regex"},
+		{name: "invalid regex", modify: func(c *DetectorConfig) { c.Regex = "(" }, wantErr: "invalid regex"},
+		{name: "invalid exclude", modify: func(c *DetectorConfig) { c.ExcludeRegexes = []string{"("} }, wantErr: "invalid exclude regex"},
+		{
+			name:    "unknown group",
+			modify:  func(c *DetectorConfig) { c.Verify = &VerifyConfig{URL: "https://example.com/{{ .token }}"} },
+			wantErr: "invalid verification",
+		},
+		{
+			name:    "no url",
+			modify:  func(c *DetectorConfig) { c.Verify = &VerifyConfig{Method: "GET"} },
+			wantErr: "no url",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.modify(&c)
+			_, err := NewRegex(c)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	err := os.WriteFile(path, []byte(`
+detectors:
+  - name: first
+    keywords: [one_]
+    regex: 'one_[0-9]+'
+  - name: second
+    keywords: [two_]
+    regex: 'two_[0-9]+'
+    verify:
+      url: 'https://example.com/{{ .match }}'
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 2 || ds[0].(*Regex).Name() != "first" || ds[1].(*Regex).Name() != "second" {
+		t.Errorf("got detectors %v", ds)
+	}
+
+	if _, err := New(DetectorConfig{Name: "a", Keywords: []string{"a"}, Regex: "a"}, DetectorConfig{Name: "a", Keywords: []string{"b"}, Regex: "b"}); err == nil {
+		t.Error("detectors with the same name should fail")
+	}
+}
+
+func TestRegex_FromDataVerificationError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	d, err := NewRegex(DetectorConfig{
+		Name:     "internal token",
+		Keywords: []string{"itk_"},
+		Regex:    `itk_(?P<id>[a-z0-9]{8})_(?P<secret>[a-z]+)`,
+		Verify:   &VerifyConfig{URL: server.URL + "/tokens/{{ .id }}?secret={{ .secret }}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := d.FromData(context.Background(), true, []byte("itk_abcd1234_validsecret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d results, want 1", len(got))
+	}
+	if got[0].VerificationState() != detectors.StateUnknown {
+		t.Errorf("got state %s, want %s", got[0].VerificationState(), detectors.StateUnknown)
+	}
+	if strings.Contains(got[0].VerificationError.Error(), "validsecret") {
+		t.Errorf("verification error contains the secret: %s", got[0].VerificationError)
+	}
+}
@@ -67,11 +67,13 @@ func (d *Base64) FromChunk(chunk *sources.Chunk) *sources.Chunk {
 		}
 	}
 
+	// The decoded data goes in a copy of the chunk, so the decoders after this one get the original data.
 	if len(decodedSubstrings) > 0 {
+		decoded := *chunk
 		for substring, dec := range decodedSubstrings {
-			chunk.Data = bytes.Replace(chunk.Data, []byte(substring), dec, 1)
+			decoded.Data = bytes.Replace(decoded.Data, []byte(substring), dec, 1)
 		}
-		return chunk
+		return &decoded
 	}
 
 	return nil
@@ -8,6 +8,9 @@ func DefaultDecoders() []Decoder {
 	return []Decoder{
 		&Plain{},
 		&Base64{},
+		&Hex{},
+		&Escaped{},
+		&UTF16{},
 	}
 }
 
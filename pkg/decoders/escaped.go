@@ -0,0 +1,106 @@
+package decoders
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// Ensure the Decoder satisfies the interface at compile time
+var _ Decoder = (*Escaped)(nil)
+
+// Escaped decodes the escapes of URLs, form bodies, HTML, and JavaScript, JSON and C strings, such as "%2B", "&#43;",
+// "+" and "\x2b", which keep detectors from matching the secrets they are in.
+type Escaped struct{}
+
+var (
+	// percentTokenPat matches the parts of URLs and form bodies with percent escapes. A plus only means a space in
+	// them, elsewhere it's often part of a secret.
+	percentTokenPat = regexp.MustCompile(`[^\s"'<>]*%[0-9A-Fa-f]{2}[^\s"'<>]*`)
+	percentPat      = regexp.MustCompile(`%[0-9A-Fa-f]{2}|\+`)
+	// backslashPat matches UTF-16 surrogate pairs before single unicode escapes, so that characters outside of the
+	// basic multilingual plane are decoded as one.
+	backslashPat = regexp.MustCompile(
+		`\\u[dD][89abAB][0-9a-fA-F]{2}\\u[dD][c-fC-F][0-9a-fA-F]{2}|\\u\{[0-9a-fA-F]{1,6}\}|\\u[0-9a-fA-F]{4}|\\x[0-9a-fA-F]{2}`,
+	)
+	// htmlEntityPat only matches the named entities that are used to escape, since names such as "&copy" are also
+	// common in query strings.
+	htmlEntityPat = regexp.MustCompile(`&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|amp|lt|gt|quot|apos);`)
+)
+
+var htmlEntities = map[string]string{
+	"&amp;":  "&",
+	"&lt;":   "<",
+	"&gt;":   ">",
+	"&quot;": `"`,
+	"&apos;": "'",
+}
+
+func (d *Escaped) FromChunk(chunk *sources.Chunk) *sources.Chunk {
+	if !bytes.ContainsAny(chunk.Data, `%\&`) {
+		return nil
+	}
+
+	data := htmlEntityPat.ReplaceAllFunc(chunk.Data, unescapeHTMLEntity)
+	data = percentTokenPat.ReplaceAllFunc(data, func(token []byte) []byte {
+		return percentPat.ReplaceAllFunc(token, unescapePercent)
+	})
+	data = backslashPat.ReplaceAllFunc(data, unescapeBackslash)
+
+	if bytes.Equal(data, chunk.Data) {
+		return nil
+	}
+	decoded := *chunk
+	decoded.Data = data
+	return &decoded
+}
+
+func unescapePercent(escape []byte) []byte {
+	if escape[0] == '+' {
+		return []byte(" ")
+	}
+	b, _ := strconv.ParseUint(string(escape[1:]), 16, 8)
+	return []byte{byte(b)}
+}
+
+func unescapeBackslash(escape []byte) []byte {
+	if escape[1] == 'x' {
+		// Like in C, a hex escape is a byte rather than a character.
+		b, _ := strconv.ParseUint(string(escape[2:]), 16, 8)
+		return []byte{byte(b)}
+	}
+	if len(escape) == 12 {
+		high, _ := strconv.ParseUint(string(escape[2:6]), 16, 16)
+		low, _ := strconv.ParseUint(string(escape[8:]), 16, 16)
+		return encodeRune(utf16.DecodeRune(rune(high), rune(low)))
+	}
+	digits := bytes.Trim(escape[2:], "{}")
+	r, _ := strconv.ParseUint(string(digits), 16, 32)
+	return encodeRune(rune(r))
+}
+
+func unescapeHTMLEntity(entity []byte) []byte {
+	if s, ok := htmlEntities[string(entity)]; ok {
+		return []byte(s)
+	}
+	digits, base := entity[2:len(entity)-1], 10
+	if digits[0] == 'x' || digits[0] == 'X' {
+		digits, base = digits[1:], 16
+	}
+	r, err := strconv.ParseUint(string(digits), base, 32)
+	if err != nil {
+		return entity
+	}
+	return encodeRune(rune(r))
+}
+
+// encodeRune encodes the character in UTF-8. Code points that aren't valid characters, such as lone surrogates,
+// become the replacement character.
+func encodeRune(r rune) []byte {
+	buf := make([]byte, utf8.UTFMax)
+	return buf[:utf8.EncodeRune(buf, r)]
+}
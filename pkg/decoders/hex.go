@@ -0,0 +1,76 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// Ensure the Decoder satisfies the interface at compile time
+var _ Decoder = (*Hex)(nil)
+
+// Hex decodes runs of hex digits, such as credentials stored hex-encoded in config blobs or serialized objects.
+type Hex struct{}
+
+var hexCharset = []byte("0123456789abcdefABCDEF")
+
+// hexThreshold is the number of hex digits a run needs to be longer than to be decoded. Shorter runs are mostly
+// numbers and identifiers rather than encoded data.
+const hexThreshold = 31
+
+func (d *Hex) FromChunk(chunk *sources.Chunk) *sources.Chunk {
+	decodedSubstrings := map[string][]byte{}
+	for _, str := range getSubstringsOfCharacterSet(chunk.Data, hexCharset, hexThreshold) {
+		if dec, ok := decodeHexRun(str); ok {
+			decodedSubstrings[str] = dec
+		}
+	}
+
+	if len(decodedSubstrings) == 0 {
+		return nil
+	}
+	decoded := *chunk
+	for substring, dec := range decodedSubstrings {
+		decoded.Data = bytes.Replace(decoded.Data, []byte(substring), dec, 1)
+	}
+	return &decoded
+}
+
+// decodeHexRun decodes a run of hex digits. A run with an odd number of digits has one that isn't part of the encoded
+// data, such as the last letter of a word right before it, so it is decoded without its first or else its last digit,
+// which is kept as is.
+func decodeHexRun(str string) ([]byte, bool) {
+	if len(str)%2 == 0 {
+		return decodeHex(str)
+	}
+	if dec, ok := decodeHex(str[1:]); ok {
+		return append([]byte(str[:1]), dec...), true
+	}
+	if dec, ok := decodeHex(str[:len(str)-1]); ok {
+		return append(dec, str[len(str)-1]), true
+	}
+	return nil, false
+}
+
+// decodeHex decodes the hex digits if they encode text. Hashes and other binary values aren't worth scanning, and
+// are by far the most common long hex runs.
+func decodeHex(str string) ([]byte, bool) {
+	// Digits of mixed case are unlikely to be encoded data.
+	if str != strings.ToLower(str) && str != strings.ToUpper(str) {
+		return nil, false
+	}
+	dec, err := hex.DecodeString(str)
+	if err != nil || !utf8.Valid(dec) {
+		return nil, false
+	}
+	for _, r := range string(dec) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return nil, false
+		}
+	}
+	return dec, true
+}
@@ -0,0 +1,129 @@
+package decoders
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+func TestHex_FromChunk(t *testing.T) {
+	tests := []struct {
+		name  string
+		chunk *sources.Chunk
+		want  *sources.Chunk
+	}{
+		{
+			name: "only hex chunk",
+			chunk: &sources.Chunk{
+				Data: []byte(`6c6f6e6765722d656e636f6465642d7365637265742d74657374`),
+			},
+			want: &sources.Chunk{
+				Data: []byte(`longer-encoded-secret-test`),
+			},
+		},
+		{
+			name: "mixed content",
+			chunk: &sources.Chunk{
+				Data: []byte(`token: 6C6F6E6765722D656E636F6465642D7365637265742D74657374 # upper case`),
+			},
+			want: &sources.Chunk{
+				Data: []byte(`token: longer-encoded-secret-test # upper case`),
+			},
+		},
+		{
+			name: "no chunk",
+			chunk: &sources.Chunk{
+				Data: []byte(``),
+			},
+			want: nil,
+		},
+		{
+			name: "short hex",
+			chunk: &sources.Chunk{
+				Data: []byte(`color: 6c6f6e67`),
+			},
+			want: nil,
+		},
+		{
+			name: "hash",
+			chunk: &sources.Chunk{
+				Data: []byte(`commit 3c2a8b9d1e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b`),
+			},
+			want: nil,
+		},
+		{
+			name: "mixed case",
+			chunk: &sources.Chunk{
+				Data: []byte(`6c6f6e6765722D656E636F6465642d7365637265742d74657374`),
+			},
+			want: nil,
+		},
+		{
+			name: "odd length",
+			chunk: &sources.Chunk{
+				Data: []byte(`6c6f6e6765722d656e636f6465642d7365637265742d746573740`),
+			},
+			want: &sources.Chunk{
+				Data: []byte(`longer-encoded-secret-test0`),
+			},
+		},
+		{
+			name: "odd length after a word",
+			chunk: &sources.Chunk{
+				Data: []byte(`data6c6f6e6765722d656e636f6465642d7365637265742d74657374`),
+			},
+			want: &sources.Chunk{
+				Data: []byte(`datalonger-encoded-secret-test`),
+			},
+		},
+		{
+			name: "odd length hash",
+			chunk: &sources.Chunk{
+				Data: []byte(`commit 3c2a8b9d1e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8`),
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Hex{}
+			data := string(tt.chunk.Data)
+			got := d.FromChunk(tt.chunk)
+			if string(tt.chunk.Data) != data {
+				t.Errorf("HexFromChunk() modified the chunk data to %q", tt.chunk.Data)
+			}
+			if tt.want != nil {
+				if got == nil {
+					t.Fatal("got nil, did not want nil")
+				}
+				if diff := pretty.Compare(string(got.Data), string(tt.want.Data)); diff != "" {
+					t.Errorf("HexFromChunk() %s diff: (-got +want)\n%s", tt.name, diff)
+				}
+			} else {
+				if got != nil {
+					t.Error("Expected nil chunk")
+				}
+			}
+		})
+	}
+}
+
+func TestFuzzHex(t *testing.T) {
+	if got := Fuzz([]byte(`6c6f6e6765722d656e636f6465642d7365637265742d74657374`)); got != 1 {
+		t.Errorf("Fuzz() = %d for hex data, want 1", got)
+	}
+}
+
+func BenchmarkHexFromChunk(benchmark *testing.B) {
+	d := Hex{}
+	for name, data := range detectors.MustGetBenchmarkData() {
+		benchmark.Run(name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				d.FromChunk(&sources.Chunk{Data: data})
+			}
+		})
+	}
+}
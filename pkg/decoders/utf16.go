@@ -0,0 +1,79 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// Ensure the Decoder satisfies the interface at compile time
+var _ Decoder = (*UTF16)(nil)
+
+// UTF16 transcodes UTF-16 text, such as PowerShell scripts and registry exports from Windows, to UTF-8. The byte order
+// comes from the byte order mark, or else from where the NUL bytes of ASCII characters are.
+type UTF16 struct{}
+
+var (
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// utf16SampleSize is the number of bytes that the byte order is guessed from.
+const utf16SampleSize = 512
+
+func (d *UTF16) FromChunk(chunk *sources.Chunk) *sources.Chunk {
+	order, data := utf16ByteOrder(chunk.Data)
+	if order == nil {
+		return nil
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	decoded := *chunk
+	// Like the metadata of chunks, the decoded data is valid UTF-8 without NULs.
+	decoded.Data = []byte(sanitizer.UTF8(string(utf16.Decode(units))))
+	return &decoded
+}
+
+// utf16ByteOrder returns the byte order of the data if it's UTF-16 text, and the data without its byte order mark.
+func utf16ByteOrder(data []byte) (binary.ByteOrder, []byte) {
+	switch {
+	case bytes.HasPrefix(data, utf16LEBOM) && !bytes.HasPrefix(data, []byte{0xFF, 0xFE, 0x00, 0x00}):
+		// FF FE 00 00 is the byte order mark of UTF-32LE.
+		return binary.LittleEndian, data[len(utf16LEBOM):]
+	case bytes.HasPrefix(data, utf16BEBOM):
+		return binary.BigEndian, data[len(utf16BEBOM):]
+	}
+
+	sample := data
+	if len(sample) > utf16SampleSize {
+		sample = sample[:utf16SampleSize]
+	}
+	pairs := len(sample) / 2
+	if pairs < 4 {
+		return nil, nil
+	}
+	// In UTF-16 text that is mostly ASCII, every other byte is NUL. Chunks that start in the middle of a character
+	// look like the other byte order, which decodes their ASCII characters correctly too.
+	var littleEndian, bigEndian int
+	for i := 0; i+1 < len(sample); i += 2 {
+		switch {
+		case sample[i] != 0 && sample[i+1] == 0:
+			littleEndian++
+		case sample[i] == 0 && sample[i+1] != 0:
+			bigEndian++
+		}
+	}
+	switch {
+	case littleEndian*10 >= pairs*7:
+		return binary.LittleEndian, data
+	case bigEndian*10 >= pairs*7:
+		return binary.BigEndian, data
+	}
+	return nil, nil
+}
@@ -42,6 +42,16 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) ([]dete
 	keyMatches := keyPat.FindAllStringSubmatch(dataStr, -1)
 	secretMatches := secretPat.FindAllStringSubmatch(dataStr, -1)
 
+	var secrets []string
+	for _, secretMatch := range secretMatches {
+		if len(secretMatch) == 2 {
+			secrets = append(secrets, strings.TrimSpace(secretMatch[1]))
+		}
+	}
+	if len(secrets) == 0 {
+		return results, nil
+	}
+
 	for _, keyMatch := range keyMatches {
 		if len(keyMatch) != 2 {
 			continue
@@ -49,6 +59,9 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) ([]dete
 
 		key := strings.TrimSpace(keyMatch[1])
 
+		// The key ID alone doesn't tell apart keys with different secrets, so results are also identified by the secret
+		// that verified, or the first one that was found.
+		secret := secrets[0]
 		s := detectors.Result{
 			DetectorType: detectorspb.DetectorType_AWS,
 			Raw:          []byte(key),
@@ -56,24 +69,20 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) ([]dete
 		}
 		// TODO: Remove possible matches if they verify positive.
 		if verify {
-			for _, secretMatch := range secretMatches {
-				if len(secretMatch) != 2 {
-					continue
-				}
-
-				secret := strings.TrimSpace(secretMatch[1])
-
-				result, err := callerIdentity(ctx, key, secret)
+			for _, candidate := range secrets {
+				result, err := callerIdentity(ctx, key, candidate)
 				if err != nil {
 					// It also errors for signature mismatches on the client side before sending, and it's quite noisy.
 					continue
 				}
 				if result != nil && result.Account != nil {
 					s.Verified = true
+					secret = candidate
 					break
 				}
 			}
 		}
+		s.RawV2 = []byte(key + secret)
 
 		if !s.Verified {
 			if detectors.IsKnownFalsePositive(string(s.Raw), detectors.DefaultFalsePositives, true) {
@@ -81,9 +90,7 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) ([]dete
 			}
 		}
 
-		if len(secretMatches) > 0 {
-			results = append(results, s)
-		}
+		results = append(results, s)
 	}
 
 	return detectors.CleanResults(results), nil
@@ -99,3 +106,7 @@ func callerIdentity(ctx context.Context, key, secret string) (*sts.GetCallerIden
 	result, err := svc.GetCallerIdentity(ctx, nil)
 	return result, err
 }
+
+func (s Scanner) Type() detectorspb.DetectorType {
+	return detectorspb.DetectorType_AWS
+}
@@ -69,8 +69,10 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 				// thankfully official golang examples exist but you just need to dig their many repos https://github.com/bitfinexcom/bitfinex-api-go/blob/master/examples/v2/rest-orders/main.go
 				key := apiKeyRes
 				secret := apiSecretRes
-				http.DefaultClient = client // filed https://github.com/bitfinexcom/bitfinex-api-go/issues/238 to improve this
-				c := rest.NewClientWithURL(*api).Credentials(key, secret)
+				// The client sends its requests with the verification context, so they go through the custom transport.
+				c := rest.NewClientWithURLHttpDo(*api, func(_ *http.Client, req *http.Request) (*http.Response, error) {
+					return client.Do(req.WithContext(ctx))
+				}).Credentials(key, secret)
 
 				isValid := true // assume valid
 				_, err = c.Orders.AllHistory()
@@ -101,3 +103,7 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 
 	return detectors.CleanResults(results), nil
 }
+
+func (s Scanner) Type() detectorspb.DetectorType {
+	return detectorspb.DetectorType_Bitfinex
+}
@@ -2,6 +2,8 @@ package detectors
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -23,17 +25,131 @@ type Detector interface {
 	Keywords() []string
 }
 
+// Typed is implemented by detectors that know the DetectorType of the results they find, which metrics, detector
+// selection by type, tags and verifier limits need before the detector has found anything. All the detectors in this
+// repository implement it. Detectors that don't have the type UnknownType.
+type Typed interface {
+	// Type returns the DetectorType of the results the detector finds.
+	Type() detectorspb.DetectorType
+}
+
+// UnknownType is the type of detectors that don't implement Typed.
+const UnknownType detectorspb.DetectorType = -1
+
+// Type returns the DetectorType of the results the detector finds, or UnknownType if it doesn't implement Typed.
+func Type(d Detector) detectorspb.DetectorType {
+	if typed, ok := d.(Typed); ok {
+		return typed.Type()
+	}
+	return UnknownType
+}
+
+// Named is implemented by detectors that there can be more than one of with the same type, such as custom detectors,
+// to tell them apart.
+type Named interface {
+	// Name returns the name of the detector, which is unique among the detectors of its type.
+	Name() string
+}
+
+// Name returns the name of the detector type, followed by the name of the detector for custom detectors, such as
+// "CustomRegex/internal token". Detectors that don't implement Typed are named after their Go type.
+func Name(d Detector) string {
+	detectorType := Type(d)
+	if detectorType == UnknownType {
+		return fmt.Sprintf("%T", d)
+	}
+	name := ""
+	if named, ok := d.(Named); ok {
+		name = named.Name()
+	}
+	return detectorName(detectorType, name)
+}
+
+func detectorName(detectorType detectorspb.DetectorType, name string) string {
+	if detectorType != detectorspb.DetectorType_CustomRegex || name == "" {
+		return detectorType.String()
+	}
+	return detectorType.String() + "/" + name
+}
+
 type Result struct {
 	// DetectorType is the type of Detector.
 	DetectorType detectorspb.DetectorType
 	Verified     bool
 	// Raw contains the raw secret identifier data. Prefer IDs over secrets since it is used for deduping after hashing.
 	Raw []byte
+	// RawV2 contains the raw secret identifier along with the other parts of the secret, for secrets that have more
+	// than one, like the ID and secret of an AWS key. If it is set, it is used instead of Raw to tell secrets apart.
+	RawV2 []byte `json:"-"`
 	// Redacted contains the redacted version of the raw secret identification data for display purposes.
 	// A secret ID should be used if available.
 	Redacted       string
 	ExtraData      map[string]string
 	StructuredData *detectorspb.StructuredData
+	// VerificationError is the error that prevented verifying the result, such as a timeout or a server error of the
+	// provider. Whether the secret is valid is then unknown.
+	VerificationError error `json:"-"`
+}
+
+// DetectorName returns the Name of the detector that found the result. Custom detectors put their name in the extra
+// data of their results.
+func (r Result) DetectorName() string {
+	return detectorName(r.DetectorType, r.ExtraData["name"])
+}
+
+// VerificationState is the outcome of verifying a result.
+type VerificationState int
+
+const (
+	// StateUnverified results weren't verified, or verification showed they aren't valid.
+	StateUnverified VerificationState = iota
+	// StateVerified results are valid secrets.
+	StateVerified
+	// StateUnknown results couldn't be verified because of an error, so they may be valid.
+	StateUnknown
+)
+
+func (s VerificationState) String() string {
+	switch s {
+	case StateVerified:
+		return "verified"
+	case StateUnknown:
+		return "unknown"
+	default:
+		return "unverified"
+	}
+}
+
+// VerificationState returns the outcome of verifying the result.
+func (r Result) VerificationState() VerificationState {
+	switch {
+	case r.Verified:
+		return StateVerified
+	case r.VerificationError != nil:
+		return StateUnknown
+	default:
+		return StateUnverified
+	}
+}
+
+// SetVerificationError records an error that prevented verifying the result. Secrets are removed from the error
+// message, since the error is reported along with the result.
+func (r *Result) SetVerificationError(err error, secrets ...string) {
+	if err == nil {
+		return
+	}
+	message := err.Error()
+	redacted := false
+	for _, secret := range secrets {
+		if secret != "" && strings.Contains(message, secret) {
+			message = strings.ReplaceAll(message, secret, "[REDACTED]")
+			redacted = true
+		}
+	}
+	if redacted {
+		err = errors.New(message)
+	}
+	r.VerificationError = err
 }
 
 type ResultWithMetadata struct {
@@ -45,6 +161,13 @@ type ResultWithMetadata struct {
 	SourceType sourcespb.SourceType
 	// SourceName is the name of the Source.
 	SourceName string
+	// DecoderType is the decoder that produced the data the result was found in, such as "Plain" or "Hex".
+	//
+	// Deprecated: Use DecoderChain, which has every decoder that was applied. DecoderType is the last of them.
+	DecoderType string
+	// DecoderChain lists the decoders that were applied, in order, to produce the data the result was found in, such
+	// as ["base64", "hex"]. It's empty for results found in the data as is.
+	DecoderChain []string `json:",omitempty"`
 	Result
 }
 
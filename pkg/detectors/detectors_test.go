@@ -1,6 +1,18 @@
 package detectors
 
-import "testing"
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
 
 func TestPrefixRegex(t *testing.T) {
 	tests := []struct {
@@ -34,3 +46,130 @@ func BenchmarkPrefixRegex(b *testing.B) {
 		PrefixRegex(kws)
 	}
 }
+
+func TestResult_VerificationState(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		want   VerificationState
+	}{
+		{name: "unverified", result: Result{}, want: StateUnverified},
+		{name: "verified", result: Result{Verified: true}, want: StateVerified},
+		{name: "unknown", result: Result{VerificationError: errors.New("timeout")}, want: StateUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.VerificationState(); got != tt.want {
+				t.Errorf("VerificationState() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResult_DetectorName(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		want   string
+	}{
+		{name: "built-in", result: Result{DetectorType: detectorspb.DetectorType_AWS, ExtraData: map[string]string{"name": "x"}}, want: "AWS"},
+		{name: "custom", result: Result{DetectorType: detectorspb.DetectorType_CustomRegex, ExtraData: map[string]string{"name": "internal token"}}, want: "CustomRegex/internal token"},
+		{name: "custom without name", result: Result{DetectorType: detectorspb.DetectorType_CustomRegex}, want: "CustomRegex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.DetectorName(); got != tt.want {
+				t.Errorf("DetectorName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResult_SetVerificationError(t *testing.T) {
+	var r Result
+	r.SetVerificationError(errors.New(`Get "https://api.example.com/?key=s3cr3t": timeout`), "s3cr3t", "")
+	if r.VerificationState() != StateUnknown {
+		t.Fatalf("got state %s, want %s", r.VerificationState(), StateUnknown)
+	}
+	if got, want := r.VerificationError.Error(), `Get "https://api.example.com/?key=[REDACTED]": timeout`; got != want {
+		t.Errorf("got error %q, want %q", got, want)
+	}
+
+	r = Result{}
+	r.SetVerificationError(nil)
+	if r.VerificationState() != StateUnverified {
+		t.Errorf("got state %s, want %s", r.VerificationState(), StateUnverified)
+	}
+}
+
+// TestDetectorsUseCustomTransport checks that detectors don't send verification requests with the default HTTP client
+// or transport, which would bypass common.CustomTransport and with it the egress policy, the verification rate limits
+// and the tracking of failed requests.
+func TestDetectorsUseCustomTransport(t *testing.T) {
+	forbidden := map[string]bool{
+		"DefaultClient":    true,
+		"DefaultTransport": true,
+		"Get":              true,
+		"Head":             true,
+		"Post":             true,
+		"PostForm":         true,
+	}
+	files, err := filepath.Glob(filepath.Join("*", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("found no detector packages")
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := parser.ParseFile(fset, file, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		httpName := ""
+		for _, imp := range f.Imports {
+			if path, _ := strconv.Unquote(imp.Path.Value); path == "net/http" {
+				httpName = "http"
+				if imp.Name != nil {
+					httpName = imp.Name.Name
+				}
+			}
+		}
+		if httpName == "" {
+			continue
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if pkg, ok := n.X.(*ast.Ident); ok && pkg.Name == httpName && forbidden[n.Sel.Name] {
+					t.Errorf("%s uses http.%s, use a client from common.SaneHttpClient instead", fset.Position(n.Pos()), n.Sel.Name)
+				}
+			case *ast.CompositeLit:
+				sel, ok := n.Type.(*ast.SelectorExpr)
+				if !ok || sel.Sel.Name != "Client" {
+					return true
+				}
+				if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != httpName {
+					return true
+				}
+				for _, elt := range n.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Transport" {
+							return true
+						}
+					}
+				}
+				t.Errorf("%s creates an http.Client without a transport, use a client from common.SaneHttpClient instead", fset.Position(n.Pos()))
+			}
+			return true
+		})
+	}
+}
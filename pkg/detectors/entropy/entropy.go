@@ -0,0 +1,138 @@
+package entropy
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors/generic"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// Config sets how random a string has to be to be reported. The entropy is measured in bits per character, so the
+// highest possible entropy is 6 for base64 and 4 for hex.
+type Config struct {
+	Base64Threshold float64
+	Base64MinLength int
+	HexThreshold    float64
+	HexMinLength    int
+}
+
+// DefaultConfig is the configuration of the entropy checks of trufflehog v2.
+var DefaultConfig = Config{
+	Base64Threshold: 4.5,
+	Base64MinLength: 20,
+	HexThreshold:    3,
+	HexMinLength:    20,
+}
+
+const (
+	base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=_-"
+	hexChars    = "0123456789abcdefABCDEF"
+)
+
+type Scanner struct {
+	config  Config
+	pattern *regexp.Regexp
+	generic generic.Scanner
+}
+
+// Ensure the Scanner satisfies the interface at compile time
+var _ detectors.Detector = (*Scanner)(nil)
+
+// New returns a detector that reports base64 and hex strings with high Shannon entropy, which are likely to be
+// secrets of types without their own detector.
+func New(config Config) Scanner {
+	minLength := config.Base64MinLength
+	if config.HexMinLength < minLength {
+		minLength = config.HexMinLength
+	}
+	if minLength < 1 {
+		minLength = 1
+	}
+	return Scanner{
+		config: config,
+		// Base64 includes the hex characters, so this finds both.
+		pattern: regexp.MustCompile(fmt.Sprintf(`[%s]{%d,}`, regexp.QuoteMeta(base64Chars), minLength)),
+		generic: generic.New(),
+	}
+}
+
+// Keywords are used for efficiently pre-filtering chunks.
+// Random strings have nothing in common, so every chunk is scanned.
+func (s Scanner) Keywords() []string {
+	return []string{""}
+}
+
+// FromData will find high entropy strings in a given set of bytes. They can't be verified.
+func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (results []detectors.Result, err error) {
+	for _, match := range s.pattern.FindAllString(string(data), -1) {
+		token := strings.TrimRight(match, "=")
+		encoding, charset, threshold, minLength := "base64", base64Chars, s.config.Base64Threshold, s.config.Base64MinLength
+		if isHex(token) {
+			encoding, charset, threshold, minLength = "hex", hexChars, s.config.HexThreshold, s.config.HexMinLength
+		}
+		if len(token) < minLength {
+			continue
+		}
+
+		// Least expensive-> most expensive filters.
+		entropy := ShannonEntropy(token, charset)
+		if entropy < threshold {
+			continue
+		}
+		if detectors.IsKnownFalsePositive(token, detectors.DefaultFalsePositives, encoding == "base64") {
+			continue
+		}
+		if s.generic.IsExcluded(token, encoding == "hex") {
+			continue
+		}
+
+		results = append(results, detectors.Result{
+			DetectorType: detectorspb.DetectorType_Entropy,
+			Raw:          []byte(token),
+			ExtraData: map[string]string{
+				"encoding": encoding,
+				"entropy":  strconv.FormatFloat(entropy, 'f', 2, 64),
+			},
+		})
+	}
+	return results, nil
+}
+
+func (s Scanner) Type() detectorspb.DetectorType {
+	return detectorspb.DetectorType_Entropy
+}
+
+// ShannonEntropy returns the Shannon entropy of the characters of charset in data, in bits per character.
+func ShannonEntropy(data, charset string) float64 {
+	if data == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	for _, c := range data {
+		counts[c]++
+	}
+	var entropy float64
+	for _, c := range charset {
+		if counts[c] == 0 {
+			continue
+		}
+		p := float64(counts[c]) / float64(len(data))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune(hexChars, c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package entropy
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+func TestEntropy_FromData(t *testing.T) {
+	ctx := context.Background()
+	s := New(DefaultConfig)
+
+	tests := []struct {
+		name         string
+		data         string
+		wantRaw      string
+		wantEncoding string
+	}{
+		{name: "base64", data: `token = "q8Lr2XvT9mZp4KwN7bYc3JhF6sDg1VxAeR5u=="`, wantRaw: "q8Lr2XvT9mZp4KwN7bYc3JhF6sDg1VxAeR5u", wantEncoding: "base64"},
+		{name: "hex", data: "key: 9c0e5a3f71d4b28e6af03d1c5b97e482d6f1", wantRaw: "9c0e5a3f71d4b28e6af03d1c5b97e482d6f1", wantEncoding: "hex"},
+		{name: "too short", data: "key: Zx8Kq2Lw9Ty"},
+		{name: "low entropy", data: "aaaaaaaaaaaaaaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbb"},
+		{name: "words", data: "the_quick_brown_fox_jumps_over_the_lazy_dog"},
+		{name: "known false positive", data: "ExAmPlEzQ8r4Tk2Wm9Np5Lx7Vb3Hj6Fd1Gs0"},
+		{name: "uuid", data: "id: 3fc0b7f7-da09-4ae7-a9c8-d69824b1819b"},
+		{name: "url", data: "https://xK9mQ2vT7pL4wZ8nR3bY6.example.org/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.FromData(ctx, false, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantRaw == "" {
+				if len(got) != 0 {
+					t.Errorf("got results %q, want none", got[0].Raw)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d results, want 1", len(got))
+			}
+			if string(got[0].Raw) != tt.wantRaw || got[0].ExtraData["encoding"] != tt.wantEncoding {
+				t.Errorf("got %q encoded as %s, want %q encoded as %s", got[0].Raw, got[0].ExtraData["encoding"], tt.wantRaw, tt.wantEncoding)
+			}
+		})
+	}
+}
+
+func TestEntropy_Config(t *testing.T) {
+	ctx := context.Background()
+	data := []byte("key: 9c0e5a3f71d4b28e6af03d1c5b97e482d6f1")
+
+	strict := New(Config{Base64Threshold: 4.5, Base64MinLength: 20, HexThreshold: 4.1, HexMinLength: 20})
+	if got, _ := strict.FromData(ctx, false, data); len(got) != 0 {
+		t.Errorf("got %d results with a higher hex threshold, want none", len(got))
+	}
+	long := New(Config{Base64Threshold: 4.5, Base64MinLength: 20, HexThreshold: 3, HexMinLength: 64})
+	if got, _ := long.FromData(ctx, false, data); len(got) != 0 {
+		t.Errorf("got %d results with a longer hex minimum length, want none", len(got))
+	}
+}
+
+func TestShannonEntropy(t *testing.T) {
+	tests := []struct {
+		data string
+		want float64
+	}{
+		{data: "", want: 0},
+		{data: "aaaa", want: 0},
+		{data: "abab", want: 1},
+		{data: "0123456789abcdef", want: 4},
+	}
+	for _, tt := range tests {
+		if got := ShannonEntropy(tt.data, base64Chars); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("ShannonEntropy(%q) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkFromData(benchmark *testing.B) {
+	ctx := context.Background()
+	s := New(DefaultConfig)
+	for name, data := range detectors.MustGetBenchmarkData() {
+		benchmark.Run(name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				_, err := s.FromData(ctx, false, data)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
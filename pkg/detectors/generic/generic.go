@@ -19,8 +19,6 @@ func New() Scanner {
 		`[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-4[a-fA-F0-9]{3}-[8|9|aA|bB][a-fA-F0-9]{3}-[a-fA-F0-9]{12}`, // UUIDv4
 		`[A-Z]{2,6}\-[0-9]{2,6}`, // issue tracker
 		`#[a-fA-F0-9]{6}\b`,      // hex color code
-		`\b[A-Fa-f0-9]{64}\b`,    // hex encoded hash
-		`\b[A-Fa-f0-9]{32}\b`,    // hex encoded hash
 		`https?:\/\/(www\.)?[-a-zA-Z0-9@:%._\+~#=]{1,256}\.[a-zA-Z0-9()]{1,6}\b([-a-zA-Z0-9()@:%_\+.~#?&//=]*)`, // http
 		`\b([/]{0,1}([\w]+[/])+[\w\.]*)\b`,                 // filepath
 		`([0-9A-F]{2}[:-]){5}([0-9A-F]{2})`,                // MAC addr
@@ -28,18 +26,29 @@ func New() Scanner {
 		`[v|\-]\d\.\d`, //version
 		`\d\.\d\.\d-`,  //version
 		`[\d]{1,3}\.[\d]{1,3}\.[\d]{1,3}\.[\d]{1,3}`,      // IPs and OIDs
-		`\b[A-Fa-f0-9x]{6,99}\b`,                          // hex encoding
 		`[A-Fa-f0-9x]{2}:[A-Fa-f0-9x]{2}:[A-Fa-f0-9x]{2}`, // hex encoding
-		`[\w]+\([\w, ]+\)`,                                // function
+		`[\w]+\([\w, ]+\)`, // function
+	}
+
+	// hexPatterns match any hex encoded data.
+	hexPatterns := []string{
+		`\b[A-Fa-f0-9]{64}\b`,    // hex encoded hash
+		`\b[A-Fa-f0-9]{32}\b`,    // hex encoded hash
+		`\b[A-Fa-f0-9x]{6,99}\b`, // hex encoding
 	}
 
 	excludeMatchers := []*regexp.Regexp{}
 	for _, pat := range excludePatterns {
 		excludeMatchers = append(excludeMatchers, regexp.MustCompile(pat))
 	}
+	hexMatchers := []*regexp.Regexp{}
+	for _, pat := range hexPatterns {
+		hexMatchers = append(hexMatchers, regexp.MustCompile(pat))
+	}
 
 	return Scanner{
 		excludeMatchers: excludeMatchers,
+		hexMatchers:     hexMatchers,
 	}
 }
 
@@ -48,6 +57,7 @@ type Scanner struct {
 	badList              []string
 	programmingBookWords []string
 	excludeMatchers      []*regexp.Regexp
+	hexMatchers          []*regexp.Regexp
 }
 
 // Ensure the Scanner satisfies the interface at compile time
@@ -84,7 +94,7 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 		}
 
 		// toss any that match regexes
-		if hasReMatch(s.excludeMatchers, token) {
+		if s.IsExcluded(token, false) {
 			continue
 		}
 
@@ -109,6 +119,15 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 	return
 }
 
+// IsExcluded returns true if the token matches a pattern of data that looks random but isn't a secret, such as UUIDs,
+// URLs and file paths. If allowHex is true, the patterns that match any hex encoded data are skipped.
+func (s Scanner) IsExcluded(token string, allowHex bool) bool {
+	if hasReMatch(s.excludeMatchers, token) {
+		return true
+	}
+	return !allowHex && hasReMatch(s.hexMatchers, token)
+}
+
 func hasReMatch(matchers []*regexp.Regexp, token string) bool {
 	for _, m := range matchers {
 		if m.MatchString(token) {
@@ -137,3 +156,7 @@ func hasReMatch(matchers []*regexp.Regexp, token string) bool {
 // 	}
 // 	return words
 // }
+
+func (s Scanner) Type() detectorspb.DetectorType {
+	return detectorspb.DetectorType_Generic
+}
@@ -77,11 +77,15 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 				if res.StatusCode >= 200 && res.StatusCode < 300 {
 					var userResponse userRes
 					err = json.NewDecoder(res.Body).Decode(&userResponse)
-					res.Body.Close()
 					if err == nil {
 						s.Verified = true
 					}
+				} else if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500 {
+					s.SetVerificationError(fmt.Errorf("unexpected HTTP response status %d", res.StatusCode))
 				}
+				res.Body.Close()
+			} else {
+				s.SetVerificationError(err, token)
 			}
 		}
 
@@ -94,3 +98,7 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 
 	return
 }
+
+func (s Scanner) Type() detectorspb.DetectorType {
+	return detectorspb.DetectorType_Github
+}
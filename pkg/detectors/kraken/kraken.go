@@ -118,3 +118,7 @@ func getKrakenSignature(url_path string, values url.Values, secret []byte) strin
 	macsum := mac.Sum(nil)
 	return base64.StdEncoding.EncodeToString(macsum)
 }
+
+func (s Scanner) Type() detectorspb.DetectorType {
+	return detectorspb.DetectorType_Kraken
+}
@@ -1,9 +1,7 @@
 package uri
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"io"
 	"net/http"
 	"net/url"
@@ -26,9 +24,7 @@ var (
 	keyPat = regexp.MustCompile(`\b[a-zA-Z]{1,10}:?\/\/[-.%\w{}]{1,50}:([-.%\S]{3,50})@[-.%\w\/:]+\b`)
 )
 
-type proxyRes struct {
-	Verified bool `json:"verified"`
-}
+var client = common.SaneHttpClient()
 
 // Keywords are used for efficiently pre-filtering chunks.
 // Use identifiers in the secret preferably, or the provider name.
@@ -48,11 +44,6 @@ func allowlistedProtos(scheme string) bool {
 
 // FromData will find and optionally verify URI secrets in a given set of bytes.
 func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (results []detectors.Result, err error) {
-	//Prevent SSRF "https://us-central1-ssrfproxy.cloudfunctions.net/ssrfproxy-6d96c399-74bb-4299-b49d-c1c870277eb9"
-	//TODO add as config option
-	//TODO extend to other http outbound calls in other areas of code
-	ssrfProtectorURL := "https://us-central1-ssrfproxy.cloudfunctions.net/ssrfproxy-6d96c399-74bb-4299-b49d-c1c870277eb9"
-
 	dataStr := string(data)
 
 	matches := keyPat.FindAllStringSubmatch(dataStr, -1)
@@ -95,29 +86,14 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 			Redacted:     redact,
 		}
 
-		if verify {
-			client := common.SaneHttpClient()
-			// whitelist protocols
-
-			// Assume a 200 response is a valid credential
-			postValues := map[string]string{"protocol": parsedURL.Scheme, "credentialed_uri": urlMatch}
-			jsonValue, _ := json.Marshal(postValues)
-			req, err := http.NewRequestWithContext(ctx, "POST", ssrfProtectorURL, bytes.NewBuffer(jsonValue))
+		// Only HTTP URIs are verified. The requests are subject to the egress policy of the engine, which keeps URIs
+		// found in scanned data from reaching private networks. Connecting to mongodb, redis and ftp URIs wouldn't
+		// go through the policy, so they are reported without being verified.
+		if verify && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") {
+			verified, err := verifyURL(ctx, parsedURL)
+			s.Verified = verified
 			if err != nil {
-				continue
-			}
-			req.Header.Add("Content-Type", "application/json")
-			res, err := client.Do(req)
-			if err == nil {
-				result := proxyRes{}
-				body, err := io.ReadAll(res.Body)
-				res.Body.Close()
-				if len(body) != 0 && err == nil {
-					err = json.Unmarshal(body, &result)
-					if err == nil && result.Verified {
-						s.Verified = true
-					}
-				}
+				s.SetVerificationError(err, password)
 			}
 		}
 
@@ -130,3 +106,36 @@ func (s Scanner) FromData(ctx context.Context, verify bool, data []byte) (result
 
 	return detectors.CleanResults(results), nil
 }
+
+// verifyURL requests the URL with and without its credentials. They are valid if the request only succeeds with them.
+func verifyURL(ctx context.Context, u *url.URL) (bool, error) {
+	ok, err := requestSucceeds(ctx, u)
+	if err != nil || !ok {
+		return false, err
+	}
+	withoutCredentials := *u
+	withoutCredentials.User = nil
+	ok, err = requestSucceeds(ctx, &withoutCredentials)
+	if err != nil {
+		return false, err
+	}
+	return !ok, nil
+}
+
+func requestSucceeds(ctx context.Context, u *url.URL) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+	return res.StatusCode >= 200 && res.StatusCode < 300, nil
+}
+
+func (s Scanner) Type() detectorspb.DetectorType {
+	return detectorspb.DetectorType_URI
+}
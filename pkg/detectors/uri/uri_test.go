@@ -2,7 +2,11 @@ package uri
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -100,6 +104,52 @@ func TestURI_FromChunk(t *testing.T) {
 	}
 }
 
+func TestURI_Verification(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/public" {
+			return
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "hunter22" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	t.Cleanup(func() { common.SetEgressPolicy(common.EgressPolicy{}) })
+
+	tests := []struct {
+		name         string
+		policy       common.EgressPolicy
+		data         string
+		wantVerified bool
+		wantBlocked  bool
+	}{
+		{name: "valid", policy: common.EgressPolicy{AllowPrivateNetworks: true}, data: "http://admin:hunter22@" + host + "/private", wantVerified: true},
+		{name: "invalid", policy: common.EgressPolicy{AllowPrivateNetworks: true}, data: "http://admin:wrongpass@" + host + "/private"},
+		{name: "public page", policy: common.EgressPolicy{AllowPrivateNetworks: true}, data: "http://admin:hunter22@" + host + "/public"},
+		{name: "private network", data: "http://admin:hunter22@" + host + "/private", wantBlocked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			common.SetEgressPolicy(tt.policy)
+			ctx := common.WithVerification(context.Background(), detectorspb.DetectorType_URI.String())
+			got, err := Scanner{}.FromData(ctx, true, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d results, want 1", len(got))
+			}
+			if got[0].Verified != tt.wantVerified {
+				t.Errorf("got verified %t, want %t", got[0].Verified, tt.wantVerified)
+			}
+			if blocked := errors.Is(got[0].VerificationError, common.ErrEgressBlocked); blocked != tt.wantBlocked {
+				t.Errorf("got verification error %v, want blocked %t", got[0].VerificationError, tt.wantBlocked)
+			}
+		})
+	}
+}
+
 func BenchmarkFromData(benchmark *testing.B) {
 	ctx := context.Background()
 	s := Scanner{}
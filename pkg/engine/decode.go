@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/decoders"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// defaultDecodeDepth is the number of times decoders are applied to data they already decoded, which is enough for
+// base64 inside base64, such as Kubernetes secrets in Helm values, and one more layer.
+const defaultDecodeDepth = 3
+
+// maxDecodedChunks limits the data that decoding a single chunk can produce. Every decoder can decode the data of
+// every other one, so without a limit the decoded data grows exponentially with the depth.
+const maxDecodedChunks = 64
+
+// WithDecodeDepth applies the decoders to the data they decoded, up to depth times. A depth of 1 only decodes the
+// data of chunks.
+func WithDecodeDepth(depth int) EngineOption {
+	return func(e *Engine) {
+		e.decodeDepth = depth
+	}
+}
+
+// decodedData is data produced by a chain of decoders.
+type decodedData struct {
+	data  []byte
+	chain []string
+}
+
+// decodeChunk calls scan with the data of the chunk and the data each chain of decoders produces from it, up to the
+// decode depth. Data is scanned once, however many chains produce it.
+func (e *Engine) decodeChunk(chunk *sources.Chunk, scan func(data []byte, decoderChain []string)) {
+	seen := map[[sha256.Size]byte]struct{}{}
+	layer := []decodedData{{data: chunk.Data}}
+	decodedChunks := 0
+	for depth := 0; depth < e.decodeDepth && len(layer) > 0; depth++ {
+		var next []decodedData
+		for _, input := range layer {
+			inputChunk := *chunk
+			inputChunk.Data = input.data
+			for _, decoder := range e.decoders {
+				decoded := decoder.FromChunk(&inputChunk)
+				if decoded == nil {
+					continue
+				}
+				// Decoders that leave the data as is, like Plain, aren't part of the chain. Their data only needs to be
+				// scanned the first time.
+				if bytes.Equal(decoded.Data, input.data) {
+					if depth > 0 {
+						continue
+					}
+					e.metrics.decoderHit(decoder)
+					scan(decoded.Data, input.chain)
+					continue
+				}
+
+				sum := sha256.Sum256(decoded.Data)
+				if _, ok := seen[sum]; ok {
+					continue
+				}
+				seen[sum] = struct{}{}
+				if decodedChunks == maxDecodedChunks {
+					logrus.WithField("metadata", chunk.SourceMetadata).Debugf("chunk decoded to over %d chunks, skipping the rest", maxDecodedChunks)
+					return
+				}
+				decodedChunks++
+
+				e.metrics.decoderHit(decoder)
+				chain := append(input.chain[:len(input.chain):len(input.chain)], decoderChainName(decoder))
+				scan(decoded.Data, chain)
+				next = append(next, decodedData{data: decoded.Data, chain: chain})
+			}
+		}
+		layer = next
+	}
+}
+
+// decoderType returns the name of the last decoder of the chain, such as "Hex", or "Plain" for data that wasn't
+// decoded.
+func (e *Engine) decoderType(decoderChain []string) string {
+	if len(decoderChain) == 0 {
+		return decoderName(&decoders.Plain{})
+	}
+	last := decoderChain[len(decoderChain)-1]
+	for _, decoder := range e.decoders {
+		if decoderChainName(decoder) == last {
+			return decoderName(decoder)
+		}
+	}
+	return last
+}
+
+// decoderChainName returns the name of the decoder in decoder chains, such as "base64".
+func decoderChainName(decoder decoders.Decoder) string {
+	return strings.ToLower(decoderName(decoder))
+}
@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+// DedupedResult is a unique secret along with every location it was found in.
+type DedupedResult struct {
+	// Result is the occurrence of the secret with the best verification state, or the first one that was seen.
+	detectors.ResultWithMetadata
+	// Count is the number of times the secret was found.
+	Count int
+	// Locations holds the source metadata of every occurrence, in the order they were found.
+	Locations []*source_metadatapb.MetaData
+}
+
+// deduper tracks the results that have already been emitted so that the same secret is only reported once.
+type deduper struct {
+	mu sync.Mutex
+	// includeLocation adds the source metadata to the fingerprint, so the same secret found in different places
+	// is still reported once per place.
+	includeLocation bool
+	results         map[string]*DedupedResult
+	order           []string
+}
+
+func newDeduper(includeLocation bool) *deduper {
+	return &deduper{
+		includeLocation: includeLocation,
+		results:         map[string]*DedupedResult{},
+	}
+}
+
+// secretID returns the raw secret that tells the result apart from other results of the same detector.
+func secretID(r *detectors.Result) []byte {
+	if len(r.RawV2) > 0 {
+		return r.RawV2
+	}
+	return r.Raw
+}
+
+// Fingerprint returns a stable identifier for a result made up of the detector name and the raw secret, and
+// optionally the location it was found in.
+func Fingerprint(r *detectors.ResultWithMetadata, includeLocation bool) string {
+	h := sha256.New()
+	h.Write([]byte(r.DetectorName()))
+	h.Write([]byte{0})
+	h.Write(secretID(&r.Result))
+	if includeLocation {
+		h.Write([]byte{0})
+		h.Write([]byte(r.SourceType.String()))
+		h.Write([]byte{0})
+		loc, err := json.Marshal(r.SourceMetadata)
+		if err != nil {
+			logrus.WithError(err).Debug("could not marshal source metadata for fingerprint")
+		}
+		h.Write(loc)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// verificationRank orders verification states from the least to the most useful to report.
+func verificationRank(state detectors.VerificationState) int {
+	switch state {
+	case detectors.StateVerified:
+		return 2
+	case detectors.StateUnknown:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// add records an occurrence of the result and returns true if it should be emitted, which is the first time the
+// secret has been seen, or when it has a better verification state than the occurrences seen before. A secret that
+// was first found unverified is emitted again once it is verified, so that it isn't filtered out with only verified
+// results.
+func (d *deduper) add(r detectors.ResultWithMetadata) bool {
+	key := Fingerprint(&r, d.includeLocation)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if existing, ok := d.results[key]; ok {
+		existing.Count++
+		existing.Locations = append(existing.Locations, r.SourceMetadata)
+		if verificationRank(r.VerificationState()) <= verificationRank(existing.VerificationState()) {
+			return false
+		}
+		existing.ResultWithMetadata = r
+		return true
+	}
+	d.results[key] = &DedupedResult{
+		ResultWithMetadata: r,
+		Count:              1,
+		Locations:          []*source_metadatapb.MetaData{r.SourceMetadata},
+	}
+	d.order = append(d.order, key)
+	return true
+}
+
+// list returns every unique result in the order they were first seen.
+func (d *deduper) list() []DedupedResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := make([]DedupedResult, 0, len(d.order))
+	for _, key := range d.order {
+		r := d.results[key]
+		out = append(out, DedupedResult{
+			ResultWithMetadata: r.ResultWithMetadata,
+			Count:              r.Count,
+			Locations:          append([]*source_metadatapb.MetaData(nil), r.Locations...),
+		})
+	}
+	return out
+}
+
+// DedupedResults returns every unique secret found so far along with its occurrence count and locations, sorted
+// by the number of occurrences. It returns nil if deduplication is not enabled.
+func (e *Engine) DedupedResults() []DedupedResult {
+	if e.deduper == nil {
+		return nil
+	}
+	results := e.deduper.list()
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Count > results[j].Count
+	})
+	return results
+}
@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// DetectorSelector matches detectors by their type, their name or their tags.
+type DetectorSelector struct {
+	Types []detectorspb.DetectorType
+	// Names match single detectors of a type that there can be several of, such as "CustomRegex/internal token", see
+	// detectors.Name.
+	Names []string
+	// Tags match the detectors that have any of them, see DetectorTags.
+	Tags []string
+}
+
+// Empty returns whether the selector has nothing to match.
+func (s DetectorSelector) Empty() bool {
+	return len(s.Types) == 0 && len(s.Names) == 0 && len(s.Tags) == 0
+}
+
+// Matches returns whether the detector has one of the types, names or tags of the selector.
+func (s DetectorSelector) Matches(d detectors.Detector) bool {
+	for _, t := range s.Types {
+		if detectors.Type(d) == t {
+			return true
+		}
+	}
+	for _, name := range s.Names {
+		if strings.EqualFold(detectors.Name(d), name) {
+			return true
+		}
+	}
+	for _, tag := range s.Tags {
+		if hasTag(d, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithDetectorSelection only runs the detectors whose type is in include, if it is not empty, and never runs the
+// detectors whose type is in exclude. It applies to the default detectors as well as the ones set with WithDetectors.
+func WithDetectorSelection(include, exclude []detectorspb.DetectorType) EngineOption {
+	return WithDetectorSelectors(DetectorSelector{Types: include}, DetectorSelector{Types: exclude})
+}
+
+// WithDetectorSelectors is like WithDetectorSelection, but selects detectors by tag as well as by type.
+func WithDetectorSelectors(include, exclude DetectorSelector) EngineOption {
+	return func(e *Engine) {
+		e.includeDetectors = include
+		e.excludeDetectors = exclude
+	}
+}
+
+// SelectDetectors returns the detectors whose type is in include, or all of them if include is empty, minus the ones
+// whose type is in exclude.
+func SelectDetectors(ds []detectors.Detector, include, exclude []detectorspb.DetectorType) []detectors.Detector {
+	return SelectDetectorsBy(ds, DetectorSelector{Types: include}, DetectorSelector{Types: exclude})
+}
+
+// SelectDetectorsBy returns the detectors that include matches, or all of them if include is empty, minus the ones
+// that exclude matches.
+func SelectDetectorsBy(ds []detectors.Detector, include, exclude DetectorSelector) []detectors.Detector {
+	if include.Empty() && exclude.Empty() {
+		return ds
+	}
+	selected := make([]detectors.Detector, 0, len(ds))
+	for _, d := range ds {
+		if !include.Empty() && !include.Matches(d) {
+			continue
+		}
+		if exclude.Matches(d) {
+			continue
+		}
+		selected = append(selected, d)
+	}
+	return selected
+}
+
+// selectDetectors applies the detector selection to the loaded detectors.
+func (e *Engine) selectDetectors() {
+	if e.includeDetectors.Empty() && e.excludeDetectors.Empty() {
+		return
+	}
+	loaded := map[detectorspb.DetectorType]bool{}
+	loadedNames := map[string]bool{}
+	for verify, ds := range e.detectors {
+		for _, d := range ds {
+			loaded[detectors.Type(d)] = true
+			loadedNames[strings.ToLower(detectors.Name(d))] = true
+		}
+		e.detectors[verify] = SelectDetectorsBy(ds, e.includeDetectors, e.excludeDetectors)
+	}
+	for _, t := range e.includeDetectors.Types {
+		if !loaded[t] {
+			logrus.Warnf("included detector %s is not loaded", t)
+		}
+	}
+	for _, name := range e.includeDetectors.Names {
+		if !loadedNames[strings.ToLower(name)] {
+			logrus.Warnf("included detector %s is not loaded", name)
+		}
+	}
+}
+
+// ParseDetectorSelector parses detector names like ParseDetectorTypes, as well as tags written as "tag:NAME", such as
+// "tag:cloud-provider", and single custom detectors written as "CustomRegex/NAME".
+func ParseDetectorSelector(names []string) (DetectorSelector, error) {
+	var selector DetectorSelector
+	var typeNames []string
+	for _, name := range names {
+		for _, n := range strings.Split(name, ",") {
+			n = strings.TrimSpace(n)
+			if split := strings.SplitN(n, "/", 2); len(split) == 2 {
+				detectorType, ok := detectorTypeFromName(split[0])
+				detectorName := strings.TrimSpace(split[1])
+				if !ok || detectorType != detectorspb.DetectorType_CustomRegex || detectorName == "" {
+					return DetectorSelector{}, fmt.Errorf("unknown detector %q, only custom detectors can be selected as TYPE/NAME", n)
+				}
+				selector.Names = append(selector.Names, detectorType.String()+"/"+detectorName)
+				continue
+			}
+			if !strings.HasPrefix(strings.ToLower(n), tagPrefix) {
+				typeNames = append(typeNames, n)
+				continue
+			}
+			tag := strings.ToLower(strings.TrimSpace(n[len(tagPrefix):]))
+			if !isDetectorTag(tag) {
+				return DetectorSelector{}, fmt.Errorf("unknown detector tag %q, the tags are: %s", tag, strings.Join(DetectorTagNames(), ", "))
+			}
+			selector.Tags = append(selector.Tags, tag)
+		}
+	}
+	types, err := ParseDetectorTypes(typeNames)
+	if err != nil {
+		return DetectorSelector{}, err
+	}
+	selector.Types = types
+	return selector, nil
+}
+
+const tagPrefix = "tag:"
+
+func isDetectorTag(tag string) bool {
+	for _, t := range DetectorTagNames() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseDetectorTypes looks up detector types by their case-insensitive protobuf enum names, such as "AWS" or
+// "github", or by their numeric values. Each name may also be a comma separated list.
+func ParseDetectorTypes(names []string) ([]detectorspb.DetectorType, error) {
+	var types []detectorspb.DetectorType
+	for _, name := range names {
+		for _, n := range strings.Split(name, ",") {
+			n = strings.TrimSpace(n)
+			if n == "" {
+				continue
+			}
+			if value, err := strconv.Atoi(n); err == nil {
+				if _, ok := detectorspb.DetectorType_name[int32(value)]; !ok {
+					return nil, fmt.Errorf("unknown detector %q", n)
+				}
+				types = append(types, detectorspb.DetectorType(value))
+				continue
+			}
+			detectorType, ok := detectorTypeFromName(n)
+			if !ok {
+				return nil, fmt.Errorf("unknown detector %q", n)
+			}
+			types = append(types, detectorType)
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,186 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/custom_detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+func TestParseDetectorTypes(t *testing.T) {
+	tests := []struct {
+		names   []string
+		want    []detectorspb.DetectorType
+		wantErr bool
+	}{
+		{names: nil, want: nil},
+		{names: []string{"aws"}, want: []detectorspb.DetectorType{detectorspb.DetectorType_AWS}},
+		{
+			names: []string{"AWS, github", "Generic"},
+			want:  []detectorspb.DetectorType{detectorspb.DetectorType_AWS, detectorspb.DetectorType_Github, detectorspb.DetectorType_Generic},
+		},
+		{names: []string{"2"}, want: []detectorspb.DetectorType{detectorspb.DetectorType_AWS}},
+		{names: []string{"nope"}, wantErr: true},
+		{names: []string{"-1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDetectorTypes(tt.names)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDetectorTypes(%q) error = %v, wantErr %v", tt.names, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseDetectorTypes(%q) = %v, want %v", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestSelectDetectors(t *testing.T) {
+	aws := &blockingDetector{detectorType: detectorspb.DetectorType_AWS}
+	github := &blockingDetector{detectorType: detectorspb.DetectorType_Github}
+	generic := &blockingDetector{detectorType: detectorspb.DetectorType_Generic}
+	all := []detectors.Detector{aws, github, generic}
+
+	tests := []struct {
+		name    string
+		include []detectorspb.DetectorType
+		exclude []detectorspb.DetectorType
+		want    []detectors.Detector
+	}{
+		{name: "all", want: all},
+		{name: "include", include: []detectorspb.DetectorType{detectorspb.DetectorType_AWS, detectorspb.DetectorType_Generic}, want: []detectors.Detector{aws, generic}},
+		{name: "exclude", exclude: []detectorspb.DetectorType{detectorspb.DetectorType_Generic}, want: []detectors.Detector{aws, github}},
+		{
+			name:    "include and exclude",
+			include: []detectorspb.DetectorType{detectorspb.DetectorType_AWS, detectorspb.DetectorType_Generic},
+			exclude: []detectorspb.DetectorType{detectorspb.DetectorType_Generic},
+			want:    []detectors.Detector{aws},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SelectDetectors(all, tt.include, tt.exclude)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineDetectorSelection(t *testing.T) {
+	e := Start(context.Background(), WithConcurrency(1), WithDetectors(true, DefaultDetectors()...),
+		WithDetectorSelection(nil, []detectorspb.DetectorType{detectorspb.DetectorType_AWS}))
+	close(e.ChunksChan())
+	if err := e.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range e.detectors[true] {
+		if detectors.Type(d) == detectorspb.DetectorType_AWS {
+			t.Fatal("excluded detector was loaded")
+		}
+	}
+}
+
+func TestParseDetectorSelector(t *testing.T) {
+	tests := []struct {
+		names   []string
+		want    DetectorSelector
+		wantErr bool
+	}{
+		{names: nil, want: DetectorSelector{}},
+		{
+			names: []string{"aws,tag:Payment", "tag:cloud-provider"},
+			want:  DetectorSelector{Types: []detectorspb.DetectorType{detectorspb.DetectorType_AWS}, Tags: []string{TagPayment, TagCloudProvider}},
+		},
+		{
+			names: []string{"customregex/internal token,aws"},
+			want:  DetectorSelector{Types: []detectorspb.DetectorType{detectorspb.DetectorType_AWS}, Names: []string{"CustomRegex/internal token"}},
+		},
+		{names: []string{"tag:nope"}, wantErr: true},
+		{names: []string{"nope"}, wantErr: true},
+		{names: []string{"aws/internal token"}, wantErr: true},
+		{names: []string{"customregex/"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDetectorSelector(tt.names)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDetectorSelector(%q) error = %v, wantErr %v", tt.names, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseDetectorSelector(%q) = %+v, want %+v", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestSelectDetectorsByTag(t *testing.T) {
+	aws := &blockingDetector{detectorType: detectorspb.DetectorType_AWS}
+	gcp := &blockingDetector{detectorType: detectorspb.DetectorType_GCP}
+	stripe := &blockingDetector{detectorType: detectorspb.DetectorType_Stripe}
+	generic := &blockingDetector{detectorType: detectorspb.DetectorType_Generic}
+	all := []detectors.Detector{aws, gcp, stripe, generic}
+
+	tests := []struct {
+		name    string
+		include DetectorSelector
+		exclude DetectorSelector
+		want    []detectors.Detector
+	}{
+		{name: "include tag", include: DetectorSelector{Tags: []string{TagCloudProvider}}, want: []detectors.Detector{aws, gcp}},
+		{
+			name:    "include tag and type",
+			include: DetectorSelector{Types: []detectorspb.DetectorType{detectorspb.DetectorType_Generic}, Tags: []string{TagPayment}},
+			want:    []detectors.Detector{stripe, generic},
+		},
+		{
+			name:    "exclude type from tag",
+			include: DetectorSelector{Tags: []string{TagCloudProvider}},
+			exclude: DetectorSelector{Types: []detectorspb.DetectorType{detectorspb.DetectorType_GCP}},
+			want:    []detectors.Detector{aws},
+		},
+		{name: "exclude tag", exclude: DetectorSelector{Tags: []string{TagCloudProvider, TagGeneric}}, want: []detectors.Detector{stripe}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SelectDetectorsBy(all, tt.include, tt.exclude)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectCustomDetectorsByName(t *testing.T) {
+	ds, err := custom_detectors.New(
+		custom_detectors.DetectorConfig{Name: "internal token", Keywords: []string{"itk_"}, Regex: `itk_[a-z]+`},
+		custom_detectors.DetectorConfig{Name: "legacy token", Keywords: []string{"ltk_"}, Regex: `ltk_[a-z]+`},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := SelectDetectorsBy(ds, DetectorSelector{Names: []string{"customregex/Internal Token"}}, DetectorSelector{})
+	if !reflect.DeepEqual(got, ds[:1]) {
+		t.Errorf("got %v, want the internal token detector", got)
+	}
+	got = SelectDetectorsBy(ds, DetectorSelector{Types: []detectorspb.DetectorType{detectorspb.DetectorType_CustomRegex}},
+		DetectorSelector{Names: []string{"CustomRegex/internal token"}})
+	if !reflect.DeepEqual(got, ds[1:]) {
+		t.Errorf("got %v, want the legacy token detector", got)
+	}
+}
+
+func TestDetectorTagsAreKnown(t *testing.T) {
+	for detectorType, tags := range detectorTags {
+		if _, ok := detectorspb.DetectorType_name[int32(detectorType)]; !ok {
+			t.Errorf("tagged detector type %d doesn't exist", detectorType)
+		}
+		for _, tag := range tags {
+			if !isDetectorTag(tag) {
+				t.Errorf("detector %s has unknown tag %q", detectorType, tag)
+			}
+		}
+	}
+}
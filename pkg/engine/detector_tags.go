@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+)
+
+// Detector tags group detectors by the kind of service their secrets are for, so that they can be selected together
+// with --include-detectors tag:NAME.
+const (
+	TagCloudProvider   = "cloud-provider"
+	TagSourceControl   = "source-control"
+	TagCI              = "ci"
+	TagPackageRegistry = "package-registry"
+	TagPayment         = "payment"
+	TagMessaging       = "messaging"
+	TagDatabase        = "database"
+	TagIdentity        = "identity"
+	TagMonitoring      = "monitoring"
+	TagAI              = "ai"
+	TagGeneric         = "generic"
+	TagCustom          = "custom"
+)
+
+// detectorTags are the tags of the detector types. Detector types that aren't listed have no tags.
+var detectorTags = func() map[detectorspb.DetectorType][]string {
+	tagged := map[string][]detectorspb.DetectorType{
+		TagCloudProvider: {
+			detectorspb.DetectorType_Alibaba,
+			detectorspb.DetectorType_AWS,
+			detectorspb.DetectorType_Azure,
+			detectorspb.DetectorType_CloudflareApiToken,
+			detectorspb.DetectorType_CloudflareCaKey,
+			detectorspb.DetectorType_CloudflareGlobalApiKey,
+			detectorspb.DetectorType_Cloudways,
+			detectorspb.DetectorType_DigitalOceanSpaces,
+			detectorspb.DetectorType_DigitalOceanToken,
+			detectorspb.DetectorType_EquinixOauth,
+			detectorspb.DetectorType_GCP,
+			detectorspb.DetectorType_Heroku,
+			detectorspb.DetectorType_IbmCloudUserKey,
+			detectorspb.DetectorType_Linode,
+			detectorspb.DetectorType_Netlify,
+			detectorspb.DetectorType_ScalewayKey,
+			detectorspb.DetectorType_TencentCloudKey,
+			detectorspb.DetectorType_Vercel,
+			detectorspb.DetectorType_VultrApiKey,
+		},
+		TagSourceControl: {
+			detectorspb.DetectorType_Github,
+			detectorspb.DetectorType_GitHubApp,
+			detectorspb.DetectorType_GitHubOld,
+			detectorspb.DetectorType_Gitlab,
+		},
+		TagCI: {
+			detectorspb.DetectorType_Buildkite,
+			detectorspb.DetectorType_Circle,
+			detectorspb.DetectorType_CircleCI,
+			detectorspb.DetectorType_Codemagic,
+			detectorspb.DetectorType_Coveralls,
+			detectorspb.DetectorType_DroneCI,
+			detectorspb.DetectorType_ScrutinizerCi,
+			detectorspb.DetectorType_Semaphore,
+			detectorspb.DetectorType_TravisCI,
+		},
+		TagPackageRegistry: {
+			detectorspb.DetectorType_ArtifactoryAccessToken,
+			detectorspb.DetectorType_Cloudsmith,
+			detectorspb.DetectorType_Docker,
+			detectorspb.DetectorType_NpmToken,
+			detectorspb.DetectorType_PackageCloud,
+			detectorspb.DetectorType_RubyGems,
+		},
+		TagPayment: {
+			detectorspb.DetectorType_Authorize,
+			detectorspb.DetectorType_BraintreePayments,
+			detectorspb.DetectorType_Checkout,
+			detectorspb.DetectorType_Dwolla,
+			detectorspb.DetectorType_Flutterwave,
+			detectorspb.DetectorType_GoCardless,
+			detectorspb.DetectorType_MollieAccessToken,
+			detectorspb.DetectorType_MollieAPIKey,
+			detectorspb.DetectorType_Paddle,
+			detectorspb.DetectorType_PaypalOauth,
+			detectorspb.DetectorType_Paymongo,
+			detectorspb.DetectorType_Paystack,
+			detectorspb.DetectorType_PlaidKey,
+			detectorspb.DetectorType_PlaidToken,
+			detectorspb.DetectorType_RazorPay,
+			detectorspb.DetectorType_RechargePayments,
+			detectorspb.DetectorType_Square,
+			detectorspb.DetectorType_SquareApp,
+			detectorspb.DetectorType_Squareup,
+			detectorspb.DetectorType_Stripe,
+			detectorspb.DetectorType_Transferwise,
+			detectorspb.DetectorType_WePay,
+		},
+		TagMessaging: {
+			detectorspb.DetectorType_DiscordBotToken,
+			detectorspb.DetectorType_DiscordWebhook,
+			detectorspb.DetectorType_ElasticEmail,
+			detectorspb.DetectorType_LineMessaging,
+			detectorspb.DetectorType_LineNotify,
+			detectorspb.DetectorType_Mailchimp,
+			detectorspb.DetectorType_Mailgun,
+			detectorspb.DetectorType_MailJetBasicAuth,
+			detectorspb.DetectorType_MailJetSMS,
+			detectorspb.DetectorType_Mandrill,
+			detectorspb.DetectorType_MattermostPersonalToken,
+			detectorspb.DetectorType_MessageBird,
+			detectorspb.DetectorType_MicrosoftTeamsWebhook,
+			detectorspb.DetectorType_NexmoApiKey,
+			detectorspb.DetectorType_Plivo,
+			detectorspb.DetectorType_Postmark,
+			detectorspb.DetectorType_SendGrid,
+			detectorspb.DetectorType_SendinBlueV2,
+			detectorspb.DetectorType_Signalwire,
+			detectorspb.DetectorType_SinchMessage,
+			detectorspb.DetectorType_Slack,
+			detectorspb.DetectorType_SlackWebhook,
+			detectorspb.DetectorType_Sparkpost,
+			detectorspb.DetectorType_TelegramBotToken,
+			detectorspb.DetectorType_Telnyx,
+			detectorspb.DetectorType_Twilio,
+			detectorspb.DetectorType_Vonage,
+			detectorspb.DetectorType_Webex,
+			detectorspb.DetectorType_ZulipChat,
+		},
+		TagDatabase: {
+			detectorspb.DetectorType_AMQP,
+			detectorspb.DetectorType_Cloudant,
+			detectorspb.DetectorType_Confluent,
+			detectorspb.DetectorType_DatabricksToken,
+			detectorspb.DetectorType_JDBC,
+			detectorspb.DetectorType_PlanetScale,
+			detectorspb.DetectorType_Rockset,
+		},
+		TagIdentity: {
+			detectorspb.DetectorType_Auth0ManagementApiToken,
+			detectorspb.DetectorType_Auth0oauth,
+			detectorspb.DetectorType_Jumpcloud,
+			detectorspb.DetectorType_Loginradius,
+			detectorspb.DetectorType_Okta,
+			detectorspb.DetectorType_OneLogin,
+			detectorspb.DetectorType_Stytch,
+		},
+		TagMonitoring: {
+			detectorspb.DetectorType_AirbrakeProjectKey,
+			detectorspb.DetectorType_AirbrakeUserKey,
+			detectorspb.DetectorType_Bugsnag,
+			detectorspb.DetectorType_DatadogToken,
+			detectorspb.DetectorType_Dynatrace,
+			detectorspb.DetectorType_Grafana,
+			detectorspb.DetectorType_NewRelicPersonalApiKey,
+			detectorspb.DetectorType_PagerDutyApiKey,
+			detectorspb.DetectorType_SentryToken,
+			detectorspb.DetectorType_SplunkOberservabilityToken,
+			detectorspb.DetectorType_Statuspage,
+			detectorspb.DetectorType_SumoLogicKey,
+			detectorspb.DetectorType_UptimeRobot,
+		},
+		TagAI: {
+			detectorspb.DetectorType_OpenAI,
+		},
+		TagGeneric: {
+			detectorspb.DetectorType_Entropy,
+			detectorspb.DetectorType_Generic,
+			detectorspb.DetectorType_PrivateKey,
+			detectorspb.DetectorType_URI,
+		},
+		TagCustom: {
+			detectorspb.DetectorType_CustomRegex,
+		},
+	}
+
+	tags := map[detectorspb.DetectorType][]string{}
+	for tag, detectorTypes := range tagged {
+		for _, detectorType := range detectorTypes {
+			tags[detectorType] = append(tags[detectorType], tag)
+		}
+	}
+	for _, t := range tags {
+		sort.Strings(t)
+	}
+	return tags
+}()
+
+// DetectorTags returns the tags of the detector, such as "cloud-provider".
+func DetectorTags(d detectors.Detector) []string {
+	return detectorTags[detectors.Type(d)]
+}
+
+// DetectorTagNames returns every tag, sorted.
+func DetectorTagNames() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, tags := range detectorTags {
+		for _, tag := range tags {
+			if !seen[tag] {
+				seen[tag] = true
+				names = append(names, tag)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hasTag(d detectors.Detector, tag string) bool {
+	for _, t := range DetectorTags(d) {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,21 +1,20 @@
 package engine
 
 import (
-	"bytes"
 	"context"
 	"runtime"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/trufflesecurity/trufflehog/v3/pkg/baseline"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/decoders"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/output"
-	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
-	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
 )
 
@@ -25,8 +24,49 @@ type Engine struct {
 	results         chan detectors.ResultWithMetadata
 	decoders        []decoders.Decoder
 	detectors       map[bool][]detectors.Detector
+	prefilter       *keywordPrefilter
 	chunksScanned   uint64
 	detectorAvgTime sync.Map
+	deduper         *deduper
+	metrics         *metrics
+	// includeDetectors and excludeDetectors select which of the detectors run.
+	includeDetectors DetectorSelector
+	excludeDetectors DetectorSelector
+	// verificationCache is an optional cache of verification outcomes shared by all workers.
+	verificationCache *VerificationCache
+	// verificationPolicy restricts which chunks are verified.
+	verificationPolicy *VerificationPolicy
+	// pathFilter optionally skips chunks of files whose paths it doesn't pass.
+	pathFilter *common.Filter
+	// resumeState is an optional checkpoint the scan continues from and keeps up to date.
+	resumeState *ResumeState
+	// baseline optionally holds known secrets that aren't reported.
+	baseline *baseline.Baseline
+	// decodeDepth is the number of times decoders are applied to the data they decoded.
+	decodeDepth int
+
+	// Verification runs in its own pool, separately from detection, with a queue per detector name, so that every
+	// custom detector has its own.
+	verificationConcurrency int
+	verificationSlots       chan struct{}
+	defaultVerifierLimit    VerifierLimit
+	verifierLimits          map[detectorspb.DetectorType]VerifierLimit
+	verifiersMu             sync.Mutex
+	verifiers               map[string]*detectorVerifier
+	verifierWg              sync.WaitGroup
+
+	// collectors gather the results of the scans of a Scanner by the source ID of their chunks, instead of sending
+	// them to the results channel.
+	collectorsMu sync.Mutex
+	collectors   map[int64]*resultCollector
+
+	// sourcesWg tracks the sources that are still sending chunks.
+	sourcesWg       sync.WaitGroup
+	closeChunksOnce sync.Once
+	// done is closed once the results channel has been closed.
+	done     chan struct{}
+	errorsMu sync.Mutex
+	errors   []error
 }
 
 type EngineOption func(*Engine)
@@ -50,6 +90,64 @@ func WithDetectors(verify bool, d ...detectors.Detector) EngineOption {
 	}
 }
 
+// WithDeduplication only emits the first occurrence of each secret on the results channel, and later ones that have a
+// better verification state, such as a verified occurrence of a secret first found unverified. Further occurrences are
+// counted and can be retrieved with DedupedResults. If includeLocation is true, the same secret found in different
+// locations is reported once per location.
+func WithDeduplication(includeLocation bool) EngineOption {
+	return func(e *Engine) {
+		e.deduper = newDeduper(includeLocation)
+	}
+}
+
+// WithVerificationCache reuses the verification outcome of secrets that have already been verified.
+func WithVerificationCache(cache *VerificationCache) EngineOption {
+	return func(e *Engine) {
+		e.verificationCache = cache
+	}
+}
+
+// WithVerificationPolicy only verifies the secrets in chunks that the policy allows. Chunks that don't have Verify
+// set are never verified.
+func WithVerificationPolicy(policy *VerificationPolicy) EngineOption {
+	return func(e *Engine) {
+		e.verificationPolicy = policy
+	}
+}
+
+// WithVerificationConcurrency sets the size of the pool that verifies results, separately from the detection workers.
+func WithVerificationConcurrency(concurrency int) EngineOption {
+	return func(e *Engine) {
+		e.verificationConcurrency = concurrency
+	}
+}
+
+// WithVerifierLimits sets the limit used to verify results of every detector type without its own entry in limits. The
+// limit of CustomRegex applies to each custom detector separately.
+func WithVerifierLimits(defaultLimit VerifierLimit, limits map[detectorspb.DetectorType]VerifierLimit) EngineOption {
+	return func(e *Engine) {
+		e.defaultVerifierLimit = defaultLimit
+		for detectorType, limit := range limits {
+			e.verifierLimits[detectorType] = limit
+		}
+	}
+}
+
+// WithPathFilter only scans the chunks of files whose paths pass the filter. Chunks of sources that don't report a
+// file are always scanned.
+func WithPathFilter(filter *common.Filter) EngineOption {
+	return func(e *Engine) {
+		e.pathFilter = filter
+	}
+}
+
+// WithBaseline doesn't report the results that are in the baseline.
+func WithBaseline(b *baseline.Baseline) EngineOption {
+	return func(e *Engine) {
+		e.baseline = b
+	}
+}
+
 func WithDecoders(decoders ...decoders.Decoder) EngineOption {
 	return func(e *Engine) {
 		e.decoders = decoders
@@ -61,6 +159,10 @@ func Start(ctx context.Context, options ...EngineOption) *Engine {
 		chunks:          make(chan *sources.Chunk),
 		results:         make(chan detectors.ResultWithMetadata),
 		detectorAvgTime: sync.Map{},
+		verifierLimits:  map[detectorspb.DetectorType]VerifierLimit{},
+		verifiers:       map[string]*detectorVerifier{},
+		done:            make(chan struct{}),
+		metrics:         newMetrics(),
 	}
 
 	for _, option := range options {
@@ -76,24 +178,25 @@ func Start(ctx context.Context, options ...EngineOption) *Engine {
 	}
 	logrus.Debugf("running with up to %d workers", e.concurrency)
 
-	var workerWg sync.WaitGroup
-	for i := 0; i < e.concurrency; i++ {
-		workerWg.Add(1)
-		go func() {
-			e.detectorWorker(ctx)
-			workerWg.Done()
-		}()
+	if e.verificationConcurrency == 0 {
+		e.verificationConcurrency = e.concurrency
 	}
+	e.verificationSlots = make(chan struct{}, e.verificationConcurrency)
+	logrus.Debugf("running with up to %d verification workers", e.verificationConcurrency)
 
 	if len(e.decoders) == 0 {
 		e.decoders = decoders.DefaultDecoders()
 	}
+	if e.decodeDepth <= 0 {
+		e.decodeDepth = defaultDecodeDepth
+	}
 
 	if len(e.detectors) == 0 {
 		e.detectors = map[bool][]detectors.Detector{}
 		e.detectors[true] = DefaultDetectors()
 		e.detectors[false] = []detectors.Detector{}
 	}
+	e.selectDetectors()
 
 	logrus.Debugf("loaded %d decoders", len(e.decoders))
 	logrus.Debugf("loaded %d detectors total, %d with verification enabled. %d with verification disabled",
@@ -101,14 +204,27 @@ func Start(ctx context.Context, options ...EngineOption) *Engine {
 		len(e.detectors[true]),
 		len(e.detectors[false]))
 
+	// Build the keyword automaton once so each chunk only needs a single pass to find candidate detectors.
+	e.prefilter = newKeywordPrefilter(e.detectors)
+
 	// start the workers
+	var workerWg sync.WaitGroup
+	for i := 0; i < e.concurrency; i++ {
+		workerWg.Add(1)
+		go func() {
+			e.detectorWorker(ctx)
+			workerWg.Done()
+		}()
+	}
+
 	go func() {
 		// close results chan when all workers are done
 		workerWg.Wait()
-		// not entirely sure why results don't get processed without this pause
-		// since we've put all results on the channel at this point.
-		time.Sleep(time.Second)
+		// then wait for the chunks that are still being verified
+		e.closeVerifiers()
+		e.metrics.finish()
 		close(e.ResultsChan())
+		close(e.done)
 	}()
 
 	return e
@@ -148,126 +264,149 @@ func (e *Engine) DetectorAvgTime() map[string][]time.Duration {
 
 func (e *Engine) detectorWorker(ctx context.Context) {
 	for chunk := range e.chunks {
-		for _, decoder := range e.decoders {
-			decoded := decoder.FromChunk(chunk)
-			if decoded == nil {
-				continue
-			}
-			dataLower := strings.ToLower(string(decoded.Data))
-			for verify, detectorsSet := range e.detectors {
-				for _, detector := range detectorsSet {
-					start := time.Now()
-					foundKeyword := false
-					for _, kw := range detector.Keywords() {
-						if strings.Contains(dataLower, strings.ToLower(kw)) {
-							foundKeyword = true
-							break
-						}
-					}
-					if !foundKeyword {
-						continue
-					}
-					ctx, cancel := context.WithTimeout(ctx, time.Second*10)
-					defer cancel()
-					results, err := detector.FromData(ctx, verify, decoded.Data)
-					if err != nil {
-						logrus.WithFields(logrus.Fields{
-							"source_type": decoded.SourceType.String(),
-							"metadata":    decoded.SourceMetadata,
-						}).WithError(err).Error("could not scan chunk")
-						continue
-					}
-					for _, result := range results {
-						targetChunk := chunk
-						if isGitSource(chunk.SourceType) {
-							copyChunk := *chunk
-							targetChunk = &copyChunk
-							SetLineNumber(&copyChunk, &result)
-						}
-
-						// Check for custom false positives here. It can't be done in IsKnownFalsePositive
-						// because some detectors only run that function when results are unverified. And it
-						// can't be done earlier, in detectorWorker before running *any* detectors, because
-						// the data at that point hasn't been split into individual matches, just larger chunks
-						// that may contain multiple matches, which could lead to missing a real match if the
-						// chunk happens to also contain a custom false positive. So we check here.
-						resultWithMetadata := detectors.CopyMetadata(targetChunk, result)
-						data := string(result.Raw)
-						if detectors.GetCustomFalsePositivesFilter().Pass(data) {
-							logrus.Debugf("ignoring custom false positive '%s'", data)
-							if logrus.IsLevelEnabled(logrus.DebugLevel) {
-								output.PrintPlainOutput(&resultWithMetadata, true)
-							}
-						} else {
-							e.results <- resultWithMetadata
-						}
-					}
-					if len(results) > 0 {
-						elapsed := time.Since(start)
-						detectorName := results[0].DetectorType.String()
-						avgTimeI, ok := e.detectorAvgTime.Load(detectorName)
-						var avgTime []time.Duration
-						if ok {
-							avgTime, ok = avgTimeI.([]time.Duration)
-							if !ok {
-								continue
-							}
-						}
-						avgTime = append(avgTime, elapsed)
-						e.detectorAvgTime.Store(detectorName, avgTime)
-					}
-				}
-			}
-		}
-		atomic.AddUint64(&e.chunksScanned, 1)
+		inflight := e.resumeState.chunkStarted()
+		e.scanChunk(ctx, chunk, inflight)
+		inflight.done()
+		e.chunkDone(chunk)
 	}
 }
 
-// gitSources is a list of sources that utilize the Git source. It is stored this way because slice consts are not
-// supported.
-func gitSources() []sourcespb.SourceType {
-	return []sourcespb.SourceType{
-		sourcespb.SourceType_SOURCE_TYPE_GIT,
-		sourcespb.SourceType_SOURCE_TYPE_GITHUB,
-		sourcespb.SourceType_SOURCE_TYPE_GITLAB,
-		sourcespb.SourceType_SOURCE_TYPE_BITBUCKET,
-		sourcespb.SourceType_SOURCE_TYPE_GERRIT,
-		sourcespb.SourceType_SOURCE_TYPE_GITHUB_UNAUTHENTICATED_ORG,
-		sourcespb.SourceType_SOURCE_TYPE_PUBLIC_GIT,
+// scanChunk scans the chunk. inflight counts the chunk until it is done, including the verification of its results.
+func (e *Engine) scanChunk(ctx context.Context, chunk *sources.Chunk, inflight *inflightChunks) {
+	// Keep draining the chunks after the scan is cancelled so the sources sending them aren't blocked.
+	if common.IsDone(ctx) {
+		return
 	}
+	if e.pathFilter != nil {
+		if file := sources.MetadataFile(chunk.SourceMetadata); file != "" && !e.pathFilter.Pass(file) {
+			return
+		}
+	}
+	e.metrics.chunkScanned(chunk)
+	verify := e.verificationPolicy.ShouldVerify(chunk)
+	e.decodeChunk(chunk, func(data []byte, decoderChain []string) {
+		for _, entry := range e.prefilter.matches(data) {
+			e.metrics.keywordMatch(entry.detector)
+			e.detect(ctx, chunk, inflight, decoderChain, data, entry.detector, verify && entry.verify)
+		}
+	})
+	atomic.AddUint64(&e.chunksScanned, 1)
 }
 
-func isGitSource(sourceType sourcespb.SourceType) bool {
-	for _, i := range gitSources() {
-		if i == sourceType {
-			return true
-		}
+// detect runs the detector on the data the decoders produced. Data that needs to be verified is handed off to the
+// verification pool, which scans it with verification, unless the verification cache already knows about all of its
+// results.
+func (e *Engine) detect(ctx context.Context, chunk *sources.Chunk, inflight *inflightChunks, decoderChain []string, data []byte, detector detectors.Detector, verify bool) {
+	start := time.Now()
+	if verify && e.verificationCache == nil {
+		e.queueVerification(ctx, chunk, inflight, decoderChain, data, detector, nil, start)
+		return
+	}
+
+	detectCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	results, err := detector.FromData(detectCtx, false, data)
+	cancel()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"source_type": chunk.SourceType.String(),
+			"metadata":    chunk.SourceMetadata,
+		}).WithError(err).Error("could not scan chunk")
+		return
+	}
+	if len(results) == 0 {
+		return
 	}
-	return false
+	e.metrics.resultsFound(detector, results)
+
+	if !verify {
+		e.processResults(ctx, chunk, decoderChain, results, start)
+		return
+	}
+	if e.verificationCache.cachedVerification(results) {
+		e.metrics.verified(detector, results, 0)
+		e.processResults(ctx, chunk, decoderChain, results, start)
+		return
+	}
+	e.queueVerification(ctx, chunk, inflight, decoderChain, data, detector, results, start)
 }
 
-// SetLineNumber sets the line number for a provided source chunk with a given detector result.
-func SetLineNumber(chunk *sources.Chunk, result *detectors.Result) {
-	var startingLine *int64
-	switch metadata := chunk.SourceMetadata.GetData().(type) {
-	case *source_metadatapb.MetaData_Git:
-		startingLine = &metadata.Git.Line
-	case *source_metadatapb.MetaData_Github:
-		startingLine = &metadata.Github.Line
-	case *source_metadatapb.MetaData_Gitlab:
-		startingLine = &metadata.Gitlab.Line
-	case *source_metadatapb.MetaData_Bitbucket:
-		startingLine = &metadata.Bitbucket.Line
-	case *source_metadatapb.MetaData_Gerrit:
-		startingLine = &metadata.Gerrit.Line
+// queueVerification hands the data off to the verifier of the detector. results are the unverified results found in
+// the data, or nil if it hasn't been scanned yet. It blocks while the queue of the verifier is full, so a verifier that
+// can't keep up slows detection down instead of results being dropped or reported without verification.
+func (e *Engine) queueVerification(ctx context.Context, chunk *sources.Chunk, inflight *inflightChunks, decoderChain []string, data []byte, detector detectors.Detector, results []detectors.Result, start time.Time) {
+	// Later decoders replace the chunk's data, so the verifier gets its own copy of the chunk.
+	chunkCopy := *chunk
+	e.chunkStarted(chunk)
+	inflight.add()
+	e.verifierFor(ctx, detector).jobs <- verificationJob{
+		chunk:        &chunkCopy,
+		inflight:     inflight,
+		decoderChain: decoderChain,
+		data:         data,
+		detector:     detector,
+		results:      results,
+		start:        start,
 	}
-	lines := bytes.Split(chunk.Data, []byte("\n"))
+}
 
-	for i, line := range lines {
-		if bytes.Contains(line, result.Raw) {
-			*startingLine = *startingLine + int64(i)
-			return
+// processResults attaches the chunk metadata and the decoder chain to the results and emits them.
+func (e *Engine) processResults(ctx context.Context, chunk *sources.Chunk, decoderChain []string, results []detectors.Result, start time.Time) {
+	locator := e.newResultLocator(chunk, decoderChain)
+	decoderType := e.decoderType(decoderChain)
+	for _, result := range results {
+		targetChunk := *chunk
+		index, exact := locator.locate(&result)
+		setLocation(&targetChunk, index, exact)
+
+		// Check for custom false positives here. It can't be done in IsKnownFalsePositive
+		// because some detectors only run that function when results are unverified. And it
+		// can't be done earlier, in detectorWorker before running *any* detectors, because
+		// the data at that point hasn't been split into individual matches, just larger chunks
+		// that may contain multiple matches, which could lead to missing a real match if the
+		// chunk happens to also contain a custom false positive. So we check here.
+		resultWithMetadata := detectors.CopyMetadata(&targetChunk, result)
+		resultWithMetadata.DecoderType = decoderType
+		resultWithMetadata.DecoderChain = decoderChain
+		data := string(result.Raw)
+		if detectors.GetCustomFalsePositivesFilter().Pass(data) {
+			logrus.Debugf("ignoring custom false positive '%s'", data)
+			if logrus.IsLevelEnabled(logrus.DebugLevel) {
+				output.PrintPlainOutput(&resultWithMetadata, true)
+			}
+		} else if ignoredInline(targetChunk.Data, &result, index, exact) {
+			logrus.Debugf("ignoring %s result with an inline %s annotation", result.DetectorType, ignoreTag)
+			e.metrics.ignored(result.DetectorType)
+			if logrus.IsLevelEnabled(logrus.DebugLevel) {
+				output.PrintPlainOutput(&resultWithMetadata, true)
+			}
+		} else if e.baseline != nil && e.baseline.Contains(&resultWithMetadata) {
+			logrus.Debugf("skipping %s result that is in the baseline", result.DetectorType)
+		} else if e.resumeState.reported(&resultWithMetadata) {
+			logrus.Debugf("skipping %s result that was reported before the scan was resumed", result.DetectorType)
+		} else if e.deduper == nil || e.deduper.add(resultWithMetadata) {
+			if collector := e.collectorFor(chunk); collector != nil {
+				collector.add(resultWithMetadata)
+				continue
+			}
+			select {
+			case e.results <- resultWithMetadata:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+	if len(results) > 0 {
+		elapsed := time.Since(start)
+		detectorName := results[0].DetectorType.String()
+		avgTimeI, ok := e.detectorAvgTime.Load(detectorName)
+		var avgTime []time.Duration
+		if ok {
+			avgTime, ok = avgTimeI.([]time.Duration)
+			if !ok {
+				return
+			}
 		}
+		avgTime = append(avgTime, elapsed)
+		e.detectorAvgTime.Store(detectorName, avgTime)
 	}
-	return
 }
@@ -27,12 +27,9 @@ func (e *Engine) ScanFileSystem(ctx context.Context, directories []string) error
 	if err != nil {
 		return errors.WrapPrefix(err, "could not init filesystem source", 0)
 	}
-	go func() {
-		err := fileSystemSource.Chunks(ctx, e.ChunksChan())
-		if err != nil {
-			logrus.WithError(err).Error("error scanning filesystem")
-		}
-		close(e.ChunksChan())
-	}()
+	e.resumeState.track(resumeSourceKey(sourcespb.SourceType_SOURCE_TYPE_FILESYSTEM, "trufflehog - filesystem"), &fileSystemSource.Progress)
+	e.runSource("filesystem", func() error {
+		return fileSystemSource.Chunks(ctx, e.ChunksChan())
+	})
 	return nil
 }
@@ -13,9 +13,13 @@ import (
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
 )
 
+// gitRepoUnit is the resume unit of the repository scanned by ScanGit.
+const gitRepoUnit = "repository"
+
 func (e *Engine) ScanGit(ctx context.Context, repoPath, headRef, baseRef string, maxDepth int, filter *common.Filter) error {
 	logOptions := &gogit.LogOptions{}
 	opts := []git.ScanOption{
@@ -84,7 +88,14 @@ func (e *Engine) ScanGit(ctx context.Context, repoPath, headRef, baseRef string,
 	if headRef != "" {
 		opts = append(opts, git.ScanOptionHeadCommit(headRef))
 	}
-	scanOptions := git.NewScanOptions(opts...)
+	// The repository is the only unit of the source. It may be a temporary clone, so it isn't named by its path.
+	progress := &sources.Progress{}
+	e.resumeState.track(resumeSourceKey(sourcespb.SourceType_SOURCE_TYPE_GIT, "trufflehog - git"), progress)
+	if progress.UnitCompleted(gitRepoUnit) {
+		logrus.Infof("skipping %s, it was scanned before the scan was resumed", repoPath)
+		return nil
+	}
+	scanOptions := git.NewResumableScanOptions(progress, gitRepoUnit, opts...)
 
 	gitSource := git.NewGit(sourcespb.SourceType_SOURCE_TYPE_GIT, 0, 0, "trufflehog - git", true, runtime.NumCPU(),
 		func(file, email, commit, timestamp, repository string, line int64) *source_metadatapb.MetaData {
@@ -102,12 +113,14 @@ func (e *Engine) ScanGit(ctx context.Context, repoPath, headRef, baseRef string,
 			}
 		})
 
-	go func() {
-		err := gitSource.ScanRepo(ctx, repo, repoPath, scanOptions, e.ChunksChan())
-		if err != nil {
-			logrus.WithError(err).Fatal("could not scan repo")
+	e.runSource("git", func() error {
+		if err := gitSource.ScanRepo(ctx, repo, repoPath, scanOptions, e.ChunksChan()); err != nil {
+			return err
+		}
+		if !common.IsDone(ctx) {
+			progress.CompleteUnit(gitRepoUnit)
 		}
-		close(e.ChunksChan())
-	}()
+		return nil
+	})
 	return nil
 }
@@ -34,18 +34,15 @@ func (e *Engine) ScanGitHub(ctx context.Context, endpoint string, repos, orgs []
 		logrus.WithError(err).Error("failed to marshal github connection")
 		return err
 	}
-	err = source.Init(ctx, "trufflehog - github", 0, 0, false, &conn, concurrency)
+	err = source.Init(ctx, "trufflehog - github", 0, int64(sourcespb.SourceType_SOURCE_TYPE_GITHUB), true, &conn, concurrency)
 	if err != nil {
 		logrus.WithError(err).Error("failed to initialize github source")
 		return err
 	}
 
-	go func() {
-		err := source.Chunks(ctx, e.ChunksChan())
-		if err != nil {
-			logrus.WithError(err).Fatal("could not scan github")
-		}
-		close(e.ChunksChan())
-	}()
+	e.resumeState.track(resumeSourceKey(sourcespb.SourceType_SOURCE_TYPE_GITHUB, "trufflehog - github"), &source.Progress)
+	e.runSource("github", func() error {
+		return source.Chunks(ctx, e.ChunksChan())
+	})
 	return nil
 }
@@ -44,12 +44,9 @@ func (e *Engine) ScanGitLab(ctx context.Context, endpoint, token string, reposit
 	if err != nil {
 		return errors.WrapPrefix(err, "could not init GitLab source", 0)
 	}
-	go func() {
-		err := gitlabSource.Chunks(ctx, e.ChunksChan())
-		if err != nil {
-			logrus.WithError(err).Error("error scanning GitLab")
-		}
-		close(e.ChunksChan())
-	}()
+	e.resumeState.track(resumeSourceKey(sourcespb.SourceType_SOURCE_TYPE_GITLAB, "trufflehog - gitlab"), &gitlabSource.Progress)
+	e.runSource("GitLab", func() error {
+		return gitlabSource.Chunks(ctx, e.ChunksChan())
+	})
 	return nil
 }
@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// ignoreTag is the annotation that ignores the results found on the same line, such as:
+//
+//	password = "hunter2" # trufflehog:ignore
+//
+// Followed by a colon and a comma separated list of detector types, it only ignores the results of those detectors:
+//
+//	key = "AKIA..." // trufflehog:ignore:aws,generic
+const ignoreTag = "trufflehog:ignore"
+
+// ignoredInline returns true if one of the lines of data the result was found on has an ignore annotation that applies
+// to it. index is where the result was located in data, as returned by resultLocator.locate: the secret itself if exact
+// is true, or else the start of the line it was decoded from. Results that couldn't be located aren't ignored.
+func ignoredInline(data []byte, result *detectors.Result, index int, exact bool) bool {
+	if index < 0 || index > len(data) {
+		return false
+	}
+	end := index
+	if exact && index+len(result.Raw) <= len(data) {
+		end += len(result.Raw)
+	}
+	start := bytes.LastIndexByte(data[:index], '\n') + 1
+	if i := bytes.IndexByte(data[end:], '\n'); i >= 0 {
+		end += i
+	} else {
+		end = len(data)
+	}
+	lines := data[start:end]
+
+	for {
+		i := bytes.Index(lines, []byte(ignoreTag))
+		if i < 0 {
+			return false
+		}
+		lines = lines[i+len(ignoreTag):]
+		if len(lines) == 0 || lines[0] != ':' {
+			return true
+		}
+		scope := lines[1:]
+		if n := bytes.IndexFunc(scope, func(r rune) bool { return !isDetectorNameChar(r) }); n >= 0 {
+			scope = scope[:n]
+		}
+		if len(scope) == 0 {
+			// A colon that isn't followed by a detector, such as at the end of a sentence, doesn't limit the scope.
+			return true
+		}
+		for _, name := range strings.Split(string(scope), ",") {
+			if strings.EqualFold(name, result.DetectorType.String()) {
+				return true
+			}
+		}
+	}
+}
+
+func isDetectorNameChar(r rune) bool {
+	return r == ',' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
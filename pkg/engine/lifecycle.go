@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ScanErrors holds every error returned by the sources of a scan.
+type ScanErrors []error
+
+func (s ScanErrors) Error() string {
+	msgs := make([]string, 0, len(s))
+	for _, err := range s {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// runSource runs a source in the background and records its error. Every source must be started before Finish is
+// called.
+func (e *Engine) runSource(description string, scan func() error) {
+	e.sourcesWg.Add(1)
+	go func() {
+		defer e.sourcesWg.Done()
+		if err := scan(); err != nil {
+			logrus.WithError(err).Errorf("error scanning %s", description)
+			e.addError(err)
+		}
+	}()
+}
+
+func (e *Engine) addError(err error) {
+	e.errorsMu.Lock()
+	defer e.errorsMu.Unlock()
+	e.errors = append(e.errors, err)
+}
+
+// Finish waits for every source to finish sending chunks, closes the chunks channel, waits for the workers to
+// process the remaining chunks and then closes the results channel. The results channel must still be read while
+// Finish runs. It returns the errors of every source, or nil if there were none.
+//
+// Callers that send chunks to ChunksChan themselves must either close it or call Finish, but not both.
+func (e *Engine) Finish() error {
+	e.sourcesWg.Wait()
+	e.closeChunksOnce.Do(func() {
+		close(e.chunks)
+	})
+	return e.Wait()
+}
+
+// Wait blocks until the results channel is closed and returns the errors of every source, or nil if there were
+// none. Unlike Finish, it doesn't close the chunks channel.
+func (e *Engine) Wait() error {
+	<-e.done
+
+	e.errorsMu.Lock()
+	defer e.errorsMu.Unlock()
+	if len(e.errors) == 0 {
+		return nil
+	}
+	return append(ScanErrors(nil), e.errors...)
+}
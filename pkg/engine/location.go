@@ -0,0 +1,174 @@
+package engine
+
+import (
+	"bytes"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/decoders"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// positionFields returns the fields of the metadata that hold the position of the chunk's data, or nil for the
+// positions the source doesn't report.
+func positionFields(metadata *source_metadatapb.MetaData) (line, column, offset *int64) {
+	switch metadata := metadata.GetData().(type) {
+	case *source_metadatapb.MetaData_Git:
+		return &metadata.Git.Line, &metadata.Git.Column, nil
+	case *source_metadatapb.MetaData_Github:
+		return &metadata.Github.Line, &metadata.Github.Column, nil
+	case *source_metadatapb.MetaData_Gitlab:
+		return &metadata.Gitlab.Line, &metadata.Gitlab.Column, nil
+	case *source_metadatapb.MetaData_Bitbucket:
+		return &metadata.Bitbucket.Line, &metadata.Bitbucket.Column, nil
+	case *source_metadatapb.MetaData_Gerrit:
+		return &metadata.Gerrit.Line, &metadata.Gerrit.Column, nil
+	case *source_metadatapb.MetaData_Filesystem:
+		return &metadata.Filesystem.Line, &metadata.Filesystem.Column, &metadata.Filesystem.Offset
+	case *source_metadatapb.MetaData_S3:
+		return &metadata.S3.Line, &metadata.S3.Column, &metadata.S3.Offset
+	}
+	return nil, nil, nil
+}
+
+// SetLocation sets the line, column and byte offset of the result in the chunk's metadata, for the sources that
+// report them. Before it is called, the metadata holds the line and offset the chunk's data starts at. The metadata
+// is replaced with a copy, so the chunk it was copied from and the other results found in it are unaffected.
+//
+// The result is located at the first occurrence of its secret in the chunk's data. Secrets that aren't in it keep the
+// position of the chunk.
+func SetLocation(chunk *sources.Chunk, result *detectors.Result) {
+	setLocation(chunk, bytes.Index(chunk.Data, result.Raw), true)
+}
+
+// SetLineNumber sets the line of the result in the chunk's metadata.
+//
+// Deprecated: Use SetLocation, which also sets the column and byte offset.
+func SetLineNumber(chunk *sources.Chunk, result *detectors.Result) {
+	SetLocation(chunk, result)
+}
+
+// setLocation moves the position in a copy of the chunk's metadata to index in the chunk's data, or leaves it at the
+// start of the chunk if index is negative. The column is only set if the index is exactly where the result is.
+func setLocation(chunk *sources.Chunk, index int, exact bool) {
+	if chunk.SourceMetadata == nil {
+		return
+	}
+	metadata := proto.Clone(chunk.SourceMetadata).(*source_metadatapb.MetaData)
+	line, column, offset := positionFields(metadata)
+	if line == nil {
+		return
+	}
+	chunk.SourceMetadata = metadata
+
+	if index < 0 {
+		return
+	}
+	before := chunk.Data[:index]
+	*line += int64(bytes.Count(before, []byte("\n")))
+	if exact {
+		*column = int64(index - bytes.LastIndexByte(before, '\n'))
+	}
+	if offset != nil {
+		*offset += int64(index)
+	}
+}
+
+// resultLocator finds where the results a detector found in the data of a chunk, or in the data a chain of decoders
+// produced from it, are in the chunk's data.
+type resultLocator struct {
+	chunk *sources.Chunk
+	// decoders are the decoders of the decoder chain, in order. ok is false if one of them isn't known.
+	decoders []decoders.Decoder
+	ok       bool
+	// next is where to look for each secret next, so that the results of a secret that is in the data more than once
+	// are located at each of its occurrences in turn.
+	next map[string]int
+}
+
+func (e *Engine) newResultLocator(chunk *sources.Chunk, decoderChain []string) *resultLocator {
+	l := &resultLocator{chunk: chunk, ok: true, next: map[string]int{}}
+	for _, name := range decoderChain {
+		found := false
+		for _, decoder := range e.decoders {
+			if decoderChainName(decoder) == name {
+				l.decoders = append(l.decoders, decoder)
+				found = true
+				break
+			}
+		}
+		l.ok = l.ok && found
+	}
+	return l
+}
+
+// locate returns the index of the result in the chunk's data, or -1 if it can't be located. Results found in decoded
+// data are located at the start of the line the encoded secret ends on, and exact is false.
+func (l *resultLocator) locate(result *detectors.Result) (index int, exact bool) {
+	if len(result.Raw) == 0 {
+		return -1, false
+	}
+	if len(l.decoders) > 0 {
+		return l.locateDecoded(result.Raw), false
+	}
+
+	data := l.chunk.Data
+	from := l.next[string(result.Raw)]
+	index = bytes.Index(data[from:], result.Raw)
+	if index < 0 {
+		// There are more results than occurrences of the secret, so the others are located at the first one.
+		from = 0
+		index = bytes.Index(data, result.Raw)
+		if index < 0 {
+			return -1, false
+		}
+	}
+	index += from
+	l.next[string(result.Raw)] = index + len(result.Raw)
+	return index, true
+}
+
+// locateDecoded returns the start of the first line of the chunk's data that, decoded along with the lines before it,
+// contains the secret, or -1 if there isn't one.
+func (l *resultLocator) locateDecoded(secret []byte) int {
+	if !l.ok {
+		return -1
+	}
+	data := l.chunk.Data
+	var lineEnds []int
+	for i, b := range data {
+		if b == '\n' {
+			lineEnds = append(lineEnds, i+1)
+		}
+	}
+	if len(lineEnds) == 0 || lineEnds[len(lineEnds)-1] != len(data) {
+		lineEnds = append(lineEnds, len(data))
+	}
+
+	// Encoded data doesn't span lines, so decoding more lines never loses the secret.
+	line := sort.Search(len(lineEnds), func(i int) bool {
+		return bytes.Contains(l.decode(data[:lineEnds[i]]), secret)
+	})
+	switch {
+	case line == len(lineEnds):
+		return -1
+	case line == 0:
+		return 0
+	}
+	return lineEnds[line-1]
+}
+
+// decode applies the decoders to the data, or returns nil if one of them doesn't decode it.
+func (l *resultLocator) decode(data []byte) []byte {
+	for _, decoder := range l.decoders {
+		decoded := decoder.FromChunk(&sources.Chunk{Data: data})
+		if decoded == nil {
+			return nil
+		}
+		data = decoded.Data
+	}
+	return data
+}
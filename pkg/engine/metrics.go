@@ -0,0 +1,252 @@
+package engine
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/decoders"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// verificationLatencyBuckets are the upper bounds, in seconds, of the verification latency histogram buckets.
+var verificationLatencyBuckets = []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics is a snapshot of what the engine has done so far.
+type Metrics struct {
+	// BytesScanned is the size of the chunks scanned, before decoding.
+	BytesScanned uint64
+	// ChunksScanned is the number of chunks scanned.
+	ChunksScanned uint64
+	// ScanDuration is the time since the engine started, or the total time the scan took once it has finished.
+	ScanDuration time.Duration
+	// ChunksPerSource is the number of chunks scanned per source name, or per source type for chunks without one.
+	ChunksPerSource map[string]uint64
+	// DecoderHits is the number of chunks each decoder produced data for.
+	DecoderHits map[string]uint64
+	// Detectors holds the metrics of every detector that matched at least one chunk.
+	Detectors map[detectorspb.DetectorType]DetectorMetrics
+}
+
+// DetectorMetrics is a snapshot of what the engine has done with a single detector type.
+type DetectorMetrics struct {
+	// KeywordMatches is the number of chunks the detector ran on because they contained one of its keywords.
+	KeywordMatches uint64
+	// Results is the number of results the detector found.
+	Results uint64
+	// Verified, Unverified and VerificationErrors count the outcomes of verifying the results. Results that weren't
+	// meant to be verified aren't counted.
+	Verified           uint64
+	Unverified         uint64
+	VerificationErrors uint64
+	// Ignored is the number of results that weren't reported because of an inline ignore annotation.
+	Ignored uint64
+	// VerificationLatency is the distribution of the time it took to verify the results found in a chunk.
+	VerificationLatency Histogram
+}
+
+// Histogram is a distribution of durations.
+type Histogram struct {
+	// Buckets are the upper bounds of the buckets, in seconds.
+	Buckets []float64
+	// Counts holds the cumulative number of observations less than or equal to each bucket's upper bound.
+	Counts []uint64
+	// Count is the total number of observations.
+	Count uint64
+	// Sum is the total of all observations.
+	Sum time.Duration
+}
+
+// Mean returns the average observation, or 0 if there are none.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// metrics holds the counters the engine updates while scanning.
+type metrics struct {
+	// bytesScanned is first so it is 64-bit aligned for atomic operations.
+	bytesScanned uint64
+	start        time.Time
+	end          atomic.Value
+
+	mu              sync.RWMutex
+	chunksPerSource map[string]*uint64
+	decoderHits     map[string]*uint64
+	detectors       map[detectorspb.DetectorType]*detectorCounters
+}
+
+type detectorCounters struct {
+	keywordMatches     uint64
+	results            uint64
+	verified           uint64
+	unverified         uint64
+	verificationErrors uint64
+	ignored            uint64
+
+	mu            sync.Mutex
+	latencyCounts []uint64
+	latencyCount  uint64
+	latencySum    time.Duration
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		start:           time.Now(),
+		chunksPerSource: map[string]*uint64{},
+		decoderHits:     map[string]*uint64{},
+		detectors:       map[detectorspb.DetectorType]*detectorCounters{},
+	}
+}
+
+// counter returns the counter stored under key, adding it if needed.
+func (m *metrics) counter(counters map[string]*uint64, key string) *uint64 {
+	m.mu.RLock()
+	c, ok := counters[key]
+	m.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok = counters[key]; !ok {
+		c = new(uint64)
+		counters[key] = c
+	}
+	return c
+}
+
+func (m *metrics) detector(detectorType detectorspb.DetectorType) *detectorCounters {
+	m.mu.RLock()
+	c, ok := m.detectors[detectorType]
+	m.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok = m.detectors[detectorType]; !ok {
+		c = &detectorCounters{latencyCounts: make([]uint64, len(verificationLatencyBuckets))}
+		m.detectors[detectorType] = c
+	}
+	return c
+}
+
+func (m *metrics) chunkScanned(chunk *sources.Chunk) {
+	atomic.AddUint64(&m.bytesScanned, uint64(len(chunk.Data)))
+	source := chunk.SourceName
+	if source == "" {
+		source = chunk.SourceType.String()
+	}
+	atomic.AddUint64(m.counter(m.chunksPerSource, source), 1)
+}
+
+func (m *metrics) decoderHit(decoder decoders.Decoder) {
+	atomic.AddUint64(m.counter(m.decoderHits, decoderName(decoder)), 1)
+}
+
+func (m *metrics) keywordMatch(detector detectors.Detector) {
+	atomic.AddUint64(&m.detector(detectors.Type(detector)).keywordMatches, 1)
+}
+
+func (m *metrics) resultsFound(detector detectors.Detector, results []detectors.Result) {
+	atomic.AddUint64(&m.detector(detectors.Type(detector)).results, uint64(len(results)))
+}
+
+func (m *metrics) ignored(detectorType detectorspb.DetectorType) {
+	atomic.AddUint64(&m.detector(detectorType).ignored, 1)
+}
+
+// verified records the outcome of verifying the results found in a chunk, and how long it took if latency is not 0.
+// Results that couldn't be verified, including when the detector failed to verify any of them, have a
+// VerificationError and are counted as errors.
+func (m *metrics) verified(detector detectors.Detector, results []detectors.Result, latency time.Duration) {
+	c := m.detector(detectors.Type(detector))
+	for _, r := range results {
+		switch r.VerificationState() {
+		case detectors.StateVerified:
+			atomic.AddUint64(&c.verified, 1)
+		case detectors.StateUnknown:
+			atomic.AddUint64(&c.verificationErrors, 1)
+		default:
+			atomic.AddUint64(&c.unverified, 1)
+		}
+	}
+	if latency == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seconds := latency.Seconds()
+	for i, bound := range verificationLatencyBuckets {
+		if seconds <= bound {
+			c.latencyCounts[i]++
+		}
+	}
+	c.latencyCount++
+	c.latencySum += latency
+}
+
+func (m *metrics) finish() {
+	m.end.Store(time.Now())
+}
+
+func (m *metrics) snapshot(chunksScanned uint64) Metrics {
+	snapshot := Metrics{
+		BytesScanned:    atomic.LoadUint64(&m.bytesScanned),
+		ChunksScanned:   chunksScanned,
+		ScanDuration:    time.Since(m.start),
+		ChunksPerSource: map[string]uint64{},
+		DecoderHits:     map[string]uint64{},
+		Detectors:       map[detectorspb.DetectorType]DetectorMetrics{},
+	}
+	if end, ok := m.end.Load().(time.Time); ok {
+		snapshot.ScanDuration = end.Sub(m.start)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for source, c := range m.chunksPerSource {
+		snapshot.ChunksPerSource[source] = atomic.LoadUint64(c)
+	}
+	for decoder, c := range m.decoderHits {
+		snapshot.DecoderHits[decoder] = atomic.LoadUint64(c)
+	}
+	for detectorType, c := range m.detectors {
+		c.mu.Lock()
+		snapshot.Detectors[detectorType] = DetectorMetrics{
+			KeywordMatches:     atomic.LoadUint64(&c.keywordMatches),
+			Results:            atomic.LoadUint64(&c.results),
+			Verified:           atomic.LoadUint64(&c.verified),
+			Unverified:         atomic.LoadUint64(&c.unverified),
+			VerificationErrors: atomic.LoadUint64(&c.verificationErrors),
+			Ignored:            atomic.LoadUint64(&c.ignored),
+			VerificationLatency: Histogram{
+				Buckets: append([]float64(nil), verificationLatencyBuckets...),
+				Counts:  append([]uint64(nil), c.latencyCounts...),
+				Count:   c.latencyCount,
+				Sum:     c.latencySum,
+			},
+		}
+		c.mu.Unlock()
+	}
+	return snapshot
+}
+
+// decoderName returns the name of the decoder's type, such as "Base64".
+func decoderName(decoder decoders.Decoder) string {
+	return reflect.Indirect(reflect.ValueOf(decoder)).Type().Name()
+}
+
+// Metrics returns a snapshot of the engine's metrics. It is safe to call while the scan is running.
+func (e *Engine) Metrics() Metrics {
+	return e.metrics.snapshot(atomic.LoadUint64(&e.chunksScanned))
+}
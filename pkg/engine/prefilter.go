@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"strings"
+
+	ahocorasick "github.com/petar-dambovaliev/aho-corasick"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// detectorEntry is a detector along with whether its results should be verified.
+type detectorEntry struct {
+	detector detectors.Detector
+	verify   bool
+}
+
+// keywordPrefilter finds which detectors have a keyword in a chunk using a single pass of an Aho-Corasick automaton
+// built from the keywords of every loaded detector.
+type keywordPrefilter struct {
+	ac      ahocorasick.AhoCorasick
+	entries []detectorEntry
+	// keywordEntries maps the index of a keyword in the automaton to the entries that use it.
+	keywordEntries [][]int
+	// alwaysEntries are the entries with an empty keyword, which matches any data.
+	alwaysEntries []int
+}
+
+func newKeywordPrefilter(detectorsSets map[bool][]detectors.Detector) *keywordPrefilter {
+	p := &keywordPrefilter{}
+
+	var keywords []string
+	keywordIndex := map[string]int{}
+	// Keep the verification-enabled detectors first for a stable ordering.
+	for _, verify := range []bool{true, false} {
+		for _, d := range detectorsSets[verify] {
+			entry := len(p.entries)
+			p.entries = append(p.entries, detectorEntry{detector: d, verify: verify})
+
+			seen := map[string]struct{}{}
+			for _, kw := range d.Keywords() {
+				kw = strings.ToLower(kw)
+				if kw == "" {
+					p.alwaysEntries = append(p.alwaysEntries, entry)
+					break
+				}
+				if _, ok := seen[kw]; ok {
+					continue
+				}
+				seen[kw] = struct{}{}
+
+				i, ok := keywordIndex[kw]
+				if !ok {
+					i = len(keywords)
+					keywordIndex[kw] = i
+					keywords = append(keywords, kw)
+					p.keywordEntries = append(p.keywordEntries, nil)
+				}
+				p.keywordEntries[i] = append(p.keywordEntries[i], entry)
+			}
+		}
+	}
+
+	builder := ahocorasick.NewAhoCorasickBuilder(ahocorasick.Opts{
+		AsciiCaseInsensitive: true,
+		MatchOnlyWholeWords:  false,
+		MatchKind:            ahocorasick.StandardMatch,
+		DFA:                  true,
+	})
+	p.ac = builder.Build(keywords)
+	return p
+}
+
+// matches returns the detectors that have at least one keyword in data, in the order they were loaded.
+func (p *keywordPrefilter) matches(data []byte) []detectorEntry {
+	if len(p.entries) == 0 {
+		return nil
+	}
+
+	matched := make([]bool, len(p.entries))
+	found := 0
+	mark := func(entry int) {
+		if !matched[entry] {
+			matched[entry] = true
+			found++
+		}
+	}
+	for _, entry := range p.alwaysEntries {
+		mark(entry)
+	}
+
+	if p.ac.PatternCount() > 0 {
+		iter := p.ac.IterOverlappingByte(data)
+		for m := iter.Next(); m != nil && found < len(p.entries); m = iter.Next() {
+			for _, entry := range p.keywordEntries[m.Pattern()] {
+				mark(entry)
+			}
+		}
+	}
+
+	out := make([]detectorEntry, 0, found)
+	for i, ok := range matched {
+		if ok {
+			out = append(out, p.entries[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+const metricsNamespace = "trufflehog"
+
+var (
+	bytesScannedDesc = prometheus.NewDesc(metricsNamespace+"_bytes_scanned_total",
+		"Size of the chunks scanned, before decoding.", nil, nil)
+	chunksScannedDesc = prometheus.NewDesc(metricsNamespace+"_chunks_scanned_total",
+		"Number of chunks scanned.", nil, nil)
+	scanDurationDesc = prometheus.NewDesc(metricsNamespace+"_scan_duration_seconds",
+		"Time the scan has been running for.", nil, nil)
+	sourceChunksDesc = prometheus.NewDesc(metricsNamespace+"_source_chunks_total",
+		"Number of chunks scanned per source.", []string{"source"}, nil)
+	decoderHitsDesc = prometheus.NewDesc(metricsNamespace+"_decoder_hits_total",
+		"Number of chunks each decoder produced data for.", []string{"decoder"}, nil)
+	keywordMatchesDesc = prometheus.NewDesc(metricsNamespace+"_detector_keyword_matches_total",
+		"Number of chunks a detector ran on because they contained one of its keywords.", []string{"detector"}, nil)
+	detectorResultsDesc = prometheus.NewDesc(metricsNamespace+"_detector_results_total",
+		"Number of results a detector found.", []string{"detector"}, nil)
+	verificationsDesc = prometheus.NewDesc(metricsNamespace+"_verifications_total",
+		"Number of verification outcomes per detector.", []string{"detector", "outcome"}, nil)
+	ignoredResultsDesc = prometheus.NewDesc(metricsNamespace+"_ignored_results_total",
+		"Number of results of a detector that were ignored because of an inline annotation.", []string{"detector"}, nil)
+	verificationLatencyDesc = prometheus.NewDesc(metricsNamespace+"_verification_duration_seconds",
+		"Time it took to verify the results found in a chunk.", []string{"detector"}, nil)
+)
+
+type prometheusCollector struct {
+	engine *Engine
+}
+
+// PrometheusCollector returns a collector that exports the engine's metrics to Prometheus.
+func (e *Engine) PrometheusCollector() prometheus.Collector {
+	return prometheusCollector{engine: e}
+}
+
+func (c prometheusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- bytesScannedDesc
+	ch <- chunksScannedDesc
+	ch <- scanDurationDesc
+	ch <- sourceChunksDesc
+	ch <- decoderHitsDesc
+	ch <- keywordMatchesDesc
+	ch <- detectorResultsDesc
+	ch <- verificationsDesc
+	ch <- ignoredResultsDesc
+	ch <- verificationLatencyDesc
+}
+
+func (c prometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	m := c.engine.Metrics()
+
+	ch <- prometheus.MustNewConstMetric(bytesScannedDesc, prometheus.CounterValue, float64(m.BytesScanned))
+	ch <- prometheus.MustNewConstMetric(chunksScannedDesc, prometheus.CounterValue, float64(m.ChunksScanned))
+	ch <- prometheus.MustNewConstMetric(scanDurationDesc, prometheus.GaugeValue, m.ScanDuration.Seconds())
+	for source, count := range m.ChunksPerSource {
+		ch <- prometheus.MustNewConstMetric(sourceChunksDesc, prometheus.CounterValue, float64(count), source)
+	}
+	for decoder, count := range m.DecoderHits {
+		ch <- prometheus.MustNewConstMetric(decoderHitsDesc, prometheus.CounterValue, float64(count), decoder)
+	}
+	for detectorType, d := range m.Detectors {
+		name := detectorType.String()
+		if detectorType == detectors.UnknownType {
+			name = "Unknown"
+		}
+		ch <- prometheus.MustNewConstMetric(keywordMatchesDesc, prometheus.CounterValue, float64(d.KeywordMatches), name)
+		ch <- prometheus.MustNewConstMetric(detectorResultsDesc, prometheus.CounterValue, float64(d.Results), name)
+		ch <- prometheus.MustNewConstMetric(verificationsDesc, prometheus.CounterValue, float64(d.Verified), name, "verified")
+		ch <- prometheus.MustNewConstMetric(verificationsDesc, prometheus.CounterValue, float64(d.Unverified), name, "unverified")
+		ch <- prometheus.MustNewConstMetric(verificationsDesc, prometheus.CounterValue, float64(d.VerificationErrors), name, "error")
+		ch <- prometheus.MustNewConstMetric(ignoredResultsDesc, prometheus.CounterValue, float64(d.Ignored), name)
+
+		latency := d.VerificationLatency
+		buckets := make(map[float64]uint64, len(latency.Buckets))
+		for i, bound := range latency.Buckets {
+			buckets[bound] = latency.Counts[i]
+		}
+		ch <- prometheus.MustNewConstHistogram(verificationLatencyDesc, latency.Count, latency.Sum.Seconds(), buckets, name)
+	}
+}
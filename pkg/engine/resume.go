@@ -0,0 +1,242 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/go-errors/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// ResumeState is a checkpoint of a scan that lets an interrupted scan continue where it left off. It holds the
+// resume info of every source and the fingerprints of the results that were already reported, so they aren't
+// reported again when the parts of the sources that were in progress are scanned again.
+//
+// The state is only consistent once every chunk the sources sent has been scanned, so Save should be called after the
+// sources were stopped and the engine finished. Checkpoint saves it while the scan is running.
+type ResumeState struct {
+	mu       sync.Mutex
+	path     string
+	scan     string
+	sources  map[string]string
+	emitted  map[string]struct{}
+	progress map[string]*sources.Progress
+	// emittedOrder holds the fingerprints of emitted in the order they were reported, so the oldest are forgotten
+	// first.
+	emittedOrder []string
+	// inflight counts the chunks the engine received since the last checkpoint that it is still scanning or verifying.
+	inflight *inflightChunks
+
+	// checkpointMu serializes checkpoints. checkpointed is the resume info of the sources as of the last checkpoint.
+	checkpointMu sync.Mutex
+	checkpointed map[string]string
+}
+
+// maxEmittedFingerprints bounds the fingerprints of reported results that are kept. Once there are more, the oldest
+// are forgotten, and the results they belong to may be reported again if the scan is resumed before the parts of the
+// sources they were found in are done.
+const maxEmittedFingerprints = 100_000
+
+// inflightChunks counts chunks that are still being scanned or verified. A nil *inflightChunks counts nothing.
+type inflightChunks struct {
+	wg sync.WaitGroup
+}
+
+func (c *inflightChunks) add() {
+	if c != nil {
+		c.wg.Add(1)
+	}
+}
+
+func (c *inflightChunks) done() {
+	if c != nil {
+		c.wg.Done()
+	}
+}
+
+type resumeStateFile struct {
+	Scan    string            `json:"scan"`
+	Sources map[string]string `json:"sources"`
+	// Emitted holds the fingerprints of the reported results, oldest first.
+	Emitted []string `json:"emitted"`
+}
+
+// LoadResumeState loads the state saved at path, or creates a new one if the file doesn't exist. scan describes
+// the scan, such as its command line, so the state of a different scan isn't resumed by mistake.
+func LoadResumeState(path, scan string) (*ResumeState, error) {
+	s := &ResumeState{
+		path:     path,
+		scan:     scan,
+		sources:  map[string]string{},
+		emitted:  map[string]struct{}{},
+		progress: map[string]*sources.Progress{},
+		inflight: &inflightChunks{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not read resume state", 0)
+	}
+	var file resumeStateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errors.WrapPrefix(err, "could not parse resume state", 0)
+	}
+	if file.Scan != scan {
+		return nil, errors.Errorf("resume state %s was saved by a different scan: %s", path, file.Scan)
+	}
+	if file.Sources != nil {
+		s.sources = file.Sources
+	}
+	for _, fingerprint := range file.Emitted {
+		s.addEmitted(fingerprint)
+	}
+	logrus.Debugf("resuming scan with %d sources and %d results already reported", len(s.sources), len(s.emitted))
+	return s, nil
+}
+
+// WithResumeState continues the scan from the state and keeps it up to date.
+func WithResumeState(state *ResumeState) EngineOption {
+	return func(e *Engine) {
+		e.resumeState = state
+	}
+}
+
+// resumeSourceKey identifies a source across runs of the same scan.
+func resumeSourceKey(sourceType sourcespb.SourceType, name string) string {
+	return sourceType.String() + "/" + name
+}
+
+// track sets the resume info of the source's progress from the state and includes the progress in the state when
+// it is saved. It must be called before the source starts sending chunks.
+func (s *ResumeState) track(key string, progress *sources.Progress) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	progress.SetResumeInfo(s.sources[key])
+	s.progress[key] = progress
+}
+
+// reported records the result as reported, and returns true if it already was.
+func (s *ResumeState) reported(r *detectors.ResultWithMetadata) bool {
+	if s == nil {
+		return false
+	}
+	fingerprint := Fingerprint(r, true)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.emitted[fingerprint]; ok {
+		return true
+	}
+	s.addEmitted(fingerprint)
+	return false
+}
+
+// addEmitted records the fingerprint of a reported result, forgetting the oldest one if there are too many. The
+// mutex must be held.
+func (s *ResumeState) addEmitted(fingerprint string) {
+	if _, ok := s.emitted[fingerprint]; ok {
+		return
+	}
+	s.emitted[fingerprint] = struct{}{}
+	s.emittedOrder = append(s.emittedOrder, fingerprint)
+	if len(s.emittedOrder) > maxEmittedFingerprints {
+		delete(s.emitted, s.emittedOrder[0])
+		s.emittedOrder = s.emittedOrder[1:]
+	}
+}
+
+// chunkStarted records that the engine received a chunk, and returns the counter to call done on once the chunk and
+// the verification of its results are done.
+func (s *ResumeState) chunkStarted() *inflightChunks {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight.add()
+	return s.inflight
+}
+
+// sourceResumeInfo returns the current resume info of every source. The mutex must be held.
+func (s *ResumeState) sourceResumeInfo() map[string]string {
+	info := make(map[string]string, len(s.sources))
+	for key, resumeInfo := range s.sources {
+		info[key] = resumeInfo
+	}
+	for key, progress := range s.progress {
+		info[key] = progress.GetResumeInfo()
+	}
+	return info
+}
+
+// Checkpoint saves the state while the scan is running, so that it can be resumed even if the process is killed. It
+// should be called periodically.
+//
+// Sources record their progress once they have sent the chunks of a part of the source, before the chunks are
+// scanned. So the resume info of the sources is saved as of the previous checkpoint, once every chunk the engine
+// received before it is done, and the first checkpoint only records where the next one starts.
+func (s *ResumeState) Checkpoint() error {
+	s.checkpointMu.Lock()
+	defer s.checkpointMu.Unlock()
+
+	s.mu.Lock()
+	current := s.sourceResumeInfo()
+	inflight := s.inflight
+	s.inflight = &inflightChunks{}
+	s.mu.Unlock()
+
+	// Chunks the engine received shortly before the previous checkpoint may have been counted after it.
+	inflight.wg.Wait()
+	previous := s.checkpointed
+	s.checkpointed = current
+	if previous == nil {
+		return nil
+	}
+	return s.save(previous)
+}
+
+// Save writes the state to its file.
+func (s *ResumeState) Save() error {
+	s.mu.Lock()
+	info := s.sourceResumeInfo()
+	s.mu.Unlock()
+	return s.save(info)
+}
+
+// save writes the state to its file with the resume info of the sources.
+func (s *ResumeState) save(sourceResumeInfo map[string]string) error {
+	s.mu.Lock()
+	file := resumeStateFile{
+		Scan:    s.scan,
+		Sources: sourceResumeInfo,
+		Emitted: append([]string(nil), s.emittedOrder...),
+	}
+	s.mu.Unlock()
+	data, err := json.Marshal(file)
+	if err != nil {
+		return errors.WrapPrefix(err, "could not marshal resume state", 0)
+	}
+	if err := common.WriteFileAtomic(s.path, data); err != nil {
+		return errors.WrapPrefix(err, "could not save resume state", 0)
+	}
+	return nil
+}
+
+// Remove deletes the state file once the scan has finished and there is nothing left to resume.
+func (s *ResumeState) Remove() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.WrapPrefix(err, "could not remove resume state", 0)
+	}
+	return nil
+}
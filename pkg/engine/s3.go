@@ -46,12 +46,9 @@ func (e *Engine) ScanS3(ctx context.Context, key, secret string, cloudCred bool,
 	if err != nil {
 		return errors.WrapPrefix(err, "failed to init S3 source", 0)
 	}
-	go func() {
-		err := s3Source.Chunks(ctx, e.ChunksChan())
-		if err != nil {
-			logrus.WithError(err).Error("error scanning s3")
-		}
-		close(e.ChunksChan())
-	}()
+	e.resumeState.track(resumeSourceKey(sourcespb.SourceType_SOURCE_TYPE_S3, "trufflehog - s3"), &s3Source.Progress)
+	e.runSource("s3", func() error {
+		return s3Source.Chunks(ctx, e.ChunksChan())
+	})
 	return nil
 }
@@ -0,0 +1,252 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-errors/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// ScanOptions describes the data passed to ScanBytes and ScanReader and how to scan it.
+type ScanOptions struct {
+	// SourceName, SourceType and SourceMetadata are copied onto every result to tell where the data came from.
+	SourceName     string
+	SourceType     sourcespb.SourceType
+	SourceMetadata *source_metadatapb.MetaData
+	// Verify verifies the secrets that are found.
+	Verify bool
+	// EngineOptions configure the engine the data is scanned with by the ScanBytes and ScanReader functions. The
+	// default decoders and detectors are used unless they are set here. They are ignored by the methods of a Scanner,
+	// whose engine is configured by NewScanner.
+	EngineOptions []EngineOption
+}
+
+// ScanBytes scans the data in memory and returns the results once the scan is done. Large data is split into
+// overlapping chunks like the data of any other source. It starts an engine for the scan, so code that scans a lot
+// of small inputs should use a Scanner instead.
+func ScanBytes(ctx context.Context, data []byte, opts ScanOptions) ([]detectors.ResultWithMetadata, error) {
+	s := NewScanner(ctx, opts.EngineOptions...)
+	results, err := s.ScanBytes(ctx, data, opts)
+	if closeErr := s.Close(); err == nil {
+		err = closeErr
+	}
+	return results, err
+}
+
+// ScanReader scans everything that can be read from r and returns the results once the scan is done. Like
+// ScanBytes, it starts an engine for the scan.
+func ScanReader(ctx context.Context, r io.Reader, opts ScanOptions) ([]detectors.ResultWithMetadata, error) {
+	s := NewScanner(ctx, opts.EngineOptions...)
+	results, err := s.ScanReader(ctx, r, opts)
+	if closeErr := s.Close(); err == nil {
+		err = closeErr
+	}
+	return results, err
+}
+
+// Scanner scans data in memory with an engine that is started once and reused by every scan, so that the detectors
+// and workers aren't set up again for each input. Its methods are safe to call concurrently. State kept by the
+// engine, such as deduplication and the verification cache, is shared by all of the scans.
+type Scanner struct {
+	engine *Engine
+	lastID int64
+	// mu is held for reading while a scan sends chunks, and for writing to close the engine.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewScanner starts the engine of a Scanner. It runs until ctx is done or Close is called.
+func NewScanner(ctx context.Context, options ...EngineOption) *Scanner {
+	return &Scanner{engine: Start(ctx, options...)}
+}
+
+// ScanBytes scans the data in memory and returns the results once the scan is done.
+func (s *Scanner) ScanBytes(ctx context.Context, data []byte, opts ScanOptions) ([]detectors.ResultWithMetadata, error) {
+	return s.scan(ctx, opts, func(send chunkFunc) error {
+		return sources.ChunkBytes(ctx, data, send)
+	})
+}
+
+// ScanReader scans everything that can be read from r and returns the results once the scan is done.
+func (s *Scanner) ScanReader(ctx context.Context, r io.Reader, opts ScanOptions) ([]detectors.ResultWithMetadata, error) {
+	return s.scan(ctx, opts, func(send chunkFunc) error {
+		err := sources.ChunkReader(ctx, r, send)
+		if err != nil && !errors.Is(err, ctx.Err()) {
+			return errors.WrapPrefix(err, "could not read data", 0)
+		}
+		return err
+	})
+}
+
+// Close stops the engine of the Scanner once the scans that are still running are done. It returns the errors of
+// the engine, if any.
+func (s *Scanner) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return s.engine.Wait()
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	go func() {
+		// Nothing reads the results channel, since the results of every scan are collected separately.
+		for range s.engine.ResultsChan() {
+		}
+	}()
+	return s.engine.Finish()
+}
+
+type chunkFunc = func(data []byte, pos sources.ChunkPosition) error
+
+// scan sends the chunks that chunkData produces to the engine and collects their results. The chunks of each scan
+// have their own source ID, which tells the engine which scan their results belong to.
+func (s *Scanner) scan(ctx context.Context, opts ScanOptions, chunkData func(send chunkFunc) error) ([]detectors.ResultWithMetadata, error) {
+	e := s.engine
+	id := atomic.AddInt64(&s.lastID, 1)
+	collector := e.addCollector(id)
+
+	var err error
+	s.mu.RLock()
+	if s.closed {
+		err = errors.New("the scanner is closed")
+	} else {
+		err = chunkData(func(data []byte, pos sources.ChunkPosition) error {
+			chunk := opts.chunk(data, pos)
+			chunk.SourceID = id
+			collector.pending.Add(1)
+			if err := sources.SendChunk(ctx, e.ChunksChan(), chunk); err != nil {
+				collector.pending.Done()
+				return err
+			}
+			return nil
+		})
+	}
+	s.mu.RUnlock()
+
+	finished := make(chan struct{})
+	go func() {
+		// The collector is kept until every chunk that was sent is done, even if the scan is cancelled, so that the
+		// engine doesn't send their results to the results channel.
+		collector.pending.Wait()
+		e.removeCollector(id)
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+	}
+	results := collector.collected()
+	if err != nil {
+		return results, err
+	}
+	return results, ctx.Err()
+}
+
+// chunk returns a chunk of the data. If the metadata can hold positions, it is copied and set to the chunk's
+// position so results are located in the data as a whole.
+func (opts ScanOptions) chunk(data []byte, pos sources.ChunkPosition) *sources.Chunk {
+	metadata := opts.SourceMetadata
+	if metadata != nil {
+		metadata = proto.Clone(metadata).(*source_metadatapb.MetaData)
+		if line, _, offset := positionFields(metadata); line != nil {
+			*line = pos.Line
+			if offset != nil {
+				*offset = pos.Offset
+			}
+		}
+	}
+	return &sources.Chunk{
+		SourceName:     opts.SourceName,
+		SourceType:     opts.SourceType,
+		SourceMetadata: metadata,
+		Data:           data,
+		Verify:         opts.Verify,
+	}
+}
+
+// resultCollector gathers the results of the chunks of a single scan of a Scanner.
+type resultCollector struct {
+	// pending counts the chunks of the scan that are still being scanned or verified.
+	pending sync.WaitGroup
+	mu      sync.Mutex
+	results []detectors.ResultWithMetadata
+}
+
+func (c *resultCollector) add(r detectors.ResultWithMetadata) {
+	// The source ID only tells the scans apart inside the engine.
+	r.SourceID = 0
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, r)
+}
+
+func (c *resultCollector) collected() []detectors.ResultWithMetadata {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]detectors.ResultWithMetadata(nil), c.results...)
+}
+
+func (e *Engine) addCollector(sourceID int64) *resultCollector {
+	e.collectorsMu.Lock()
+	defer e.collectorsMu.Unlock()
+	if e.collectors == nil {
+		e.collectors = map[int64]*resultCollector{}
+	}
+	c := &resultCollector{}
+	e.collectors[sourceID] = c
+	return c
+}
+
+func (e *Engine) removeCollector(sourceID int64) {
+	e.collectorsMu.Lock()
+	defer e.collectorsMu.Unlock()
+	delete(e.collectors, sourceID)
+}
+
+// collectorFor returns the collector of the scan the chunk belongs to, or nil if its results are sent to the results
+// channel.
+func (e *Engine) collectorFor(chunk *sources.Chunk) *resultCollector {
+	e.collectorsMu.Lock()
+	defer e.collectorsMu.Unlock()
+	return e.collectors[chunk.SourceID]
+}
+
+// chunkStarted records that more work is queued for the chunk, such as verifying its results.
+func (e *Engine) chunkStarted(chunk *sources.Chunk) {
+	if c := e.collectorFor(chunk); c != nil {
+		c.pending.Add(1)
+	}
+}
+
+// chunkDone records that the chunk, or work queued for it with chunkStarted, is done.
+func (e *Engine) chunkDone(chunk *sources.Chunk) {
+	if c := e.collectorFor(chunk); c != nil {
+		c.pending.Done()
+	}
+}
+
+// collectResults finishes the scan and returns all of its results.
+func (e *Engine) collectResults(ctx context.Context) ([]detectors.ResultWithMetadata, error) {
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- e.Finish()
+	}()
+
+	var results []detectors.ResultWithMetadata
+	for r := range e.ResultsChan() {
+		results = append(results, r)
+	}
+	if err := <-errChan; err != nil {
+		return results, err
+	}
+	return results, ctx.Err()
+}
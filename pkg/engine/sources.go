@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-errors/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/filesystem"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/git"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/github"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/gitlab"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources/s3"
+)
+
+// ScanSource scans the source configured by its sourcespb connection message, such as *sourcespb.GitHub, in the
+// background. Any number of sources can be scanned by the same engine at once. The name tells the sources apart in
+// the results and must be unique to resume the scan.
+func (e *Engine) ScanSource(ctx context.Context, name string, connection proto.Message, verify bool, concurrency int) error {
+	source, err := newSource(connection)
+	if err != nil {
+		return err
+	}
+	var conn anypb.Any
+	if err := anypb.MarshalFrom(&conn, connection, proto.MarshalOptions{}); err != nil {
+		return errors.WrapPrefix(err, "could not marshal connection", 0)
+	}
+	if err := source.Init(ctx, name, 0, int64(source.Type()), verify, &conn, concurrency); err != nil {
+		return errors.WrapPrefix(err, fmt.Sprintf("could not init %s", name), 0)
+	}
+
+	e.resumeState.track(resumeSourceKey(source.Type(), name), source.GetProgress())
+	e.runSource(name, func() error {
+		return source.Chunks(ctx, e.ChunksChan())
+	})
+	return nil
+}
+
+func newSource(connection proto.Message) (sources.Source, error) {
+	switch connection.(type) {
+	case *sourcespb.Filesystem:
+		return &filesystem.Source{}, nil
+	case *sourcespb.Git:
+		return &git.Source{}, nil
+	case *sourcespb.GitHub:
+		return &github.Source{}, nil
+	case *sourcespb.GitLab:
+		return &gitlab.Source{}, nil
+	case *sourcespb.S3:
+		return &s3.Source{}, nil
+	}
+	return nil, errors.Errorf("unsupported source connection %T", connection)
+}
@@ -0,0 +1,292 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// verificationQueueSize is the number of chunks that can wait for verification per detector before detection
+// workers have to wait for them to be picked up. A provider that is slow or rate limited for long enough to fill its
+// queue therefore slows the whole scan down to its pace, rather than its secrets going unverified.
+const verificationQueueSize = 100
+
+// VerifierLimit bounds how hard the verification of a single detector type can hit its provider.
+type VerifierLimit struct {
+	// Concurrency is the maximum number of verifications running at once. 0 means it is only bounded by the size of
+	// the verification pool.
+	Concurrency int
+	// RequestsPerSecond is the maximum rate of the HTTP requests made to verify results. 0 means there is no limit.
+	RequestsPerSecond float64
+}
+
+// ParseVerifierLimit parses a limit in the form DETECTOR=CONCURRENCY[:REQUESTS_PER_SECOND], such as "aws=2:0.5".
+func ParseVerifierLimit(s string) (detectorspb.DetectorType, VerifierLimit, error) {
+	var limit VerifierLimit
+	split := strings.SplitN(s, "=", 2)
+	if len(split) != 2 {
+		return 0, limit, fmt.Errorf("invalid verifier limit %q, expected DETECTOR=CONCURRENCY[:REQUESTS_PER_SECOND]", s)
+	}
+
+	detectorType, ok := detectorTypeFromName(split[0])
+	if !ok {
+		return 0, limit, fmt.Errorf("unknown detector %q", split[0])
+	}
+
+	values := strings.SplitN(split[1], ":", 2)
+	concurrency, err := strconv.Atoi(values[0])
+	if err != nil || concurrency < 0 {
+		return 0, limit, fmt.Errorf("invalid verifier concurrency %q", values[0])
+	}
+	limit.Concurrency = concurrency
+	if len(values) == 2 {
+		rps, err := strconv.ParseFloat(values[1], 64)
+		if err != nil || rps < 0 {
+			return 0, limit, fmt.Errorf("invalid verifier requests per second %q", values[1])
+		}
+		limit.RequestsPerSecond = rps
+	}
+	return detectorType, limit, nil
+}
+
+// detectorTypeFromName looks up a detector type by its case-insensitive protobuf enum name.
+func detectorTypeFromName(name string) (detectorspb.DetectorType, bool) {
+	name = strings.TrimSpace(name)
+	for typeName, value := range detectorspb.DetectorType_value {
+		if strings.EqualFold(typeName, name) {
+			return detectorspb.DetectorType(value), true
+		}
+	}
+	return 0, false
+}
+
+// verificationJob is a chunk that produced results for a detector which still need to be verified.
+type verificationJob struct {
+	chunk *sources.Chunk
+	// inflight counts the chunk until it is verified.
+	inflight *inflightChunks
+	// decoderChain is the decoders that produced data.
+	decoderChain []string
+	data         []byte
+	detector     detectors.Detector
+	// results are the unverified results of the detector, which are reported if verification fails. They are nil if
+	// the data hasn't been scanned before it was queued.
+	results []detectors.Result
+	start   time.Time
+}
+
+// detectorVerifier runs the verifications for a single detector, so that a slow or rate limited provider only holds up
+// its own queue.
+type detectorVerifier struct {
+	detectorName string
+	concurrency  int
+	limiter      *rate.Limiter
+	jobs         chan verificationJob
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+func newDetectorVerifier(detectorName string, limit VerifierLimit, poolSize int) *detectorVerifier {
+	concurrency := limit.Concurrency
+	if concurrency <= 0 || concurrency > poolSize {
+		concurrency = poolSize
+	}
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if limit.RequestsPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(limit.RequestsPerSecond), 1)
+	}
+	return &detectorVerifier{
+		detectorName: detectorName,
+		concurrency:  concurrency,
+		limiter:      limiter,
+		jobs:         make(chan verificationJob, verificationQueueSize),
+	}
+}
+
+// backoff pauses all verifications for the detector for the given duration. It is called when the provider
+// responds with a 429 or Retry-After.
+func (v *detectorVerifier) backoff(retryAfter time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	until := time.Now().Add(retryAfter)
+	if until.After(v.pausedUntil) {
+		logrus.Debugf("rate limited verifying %s, pausing for %s", v.detectorName, retryAfter)
+		v.pausedUntil = until
+	}
+}
+
+// wait blocks until the verifier is allowed to send another verification request.
+func (v *detectorVerifier) wait(ctx context.Context) error {
+	for {
+		v.mu.Lock()
+		pause := time.Until(v.pausedUntil)
+		v.mu.Unlock()
+		if pause <= 0 {
+			break
+		}
+		timer := time.NewTimer(pause)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return v.limiter.Wait(ctx)
+}
+
+// verifierFor returns the verifier for the detector, starting its workers the first time it is needed. Detectors are
+// told apart by their name, so custom detectors don't share a verifier even though they have the same type.
+func (e *Engine) verifierFor(ctx context.Context, detector detectors.Detector) *detectorVerifier {
+	e.verifiersMu.Lock()
+	defer e.verifiersMu.Unlock()
+
+	name := detectors.Name(detector)
+	if v, ok := e.verifiers[name]; ok {
+		return v
+	}
+
+	limit, ok := e.verifierLimits[detectors.Type(detector)]
+	if !ok {
+		limit = e.defaultVerifierLimit
+	}
+	v := newDetectorVerifier(name, limit, e.verificationConcurrency)
+	e.verifiers[name] = v
+	for i := 0; i < v.concurrency; i++ {
+		e.verifierWg.Add(1)
+		go func() {
+			defer e.verifierWg.Done()
+			e.verificationWorker(ctx, v)
+		}()
+	}
+	return v
+}
+
+// closeVerifiers stops accepting verification jobs and waits for the queued ones to finish.
+func (e *Engine) closeVerifiers() {
+	e.verifiersMu.Lock()
+	for _, v := range e.verifiers {
+		close(v.jobs)
+	}
+	e.verifiersMu.Unlock()
+	e.verifierWg.Wait()
+}
+
+func (e *Engine) verificationWorker(ctx context.Context, v *detectorVerifier) {
+	for job := range v.jobs {
+		e.verify(ctx, v, job)
+		job.inflight.done()
+		e.chunkDone(job.chunk)
+	}
+}
+
+// verify scans the data of the job with verification and reports the results.
+func (e *Engine) verify(ctx context.Context, v *detectorVerifier, job verificationJob) {
+	// Only hold a slot in the shared pool while verifying. Each request waits for the rate limit of the detector, and
+	// is subject to its egress policy.
+	e.verificationSlots <- struct{}{}
+	verifyCtx := common.WithRequestLimiter(common.WithRateLimitNotifier(ctx, v.backoff), v.wait)
+	verifyCtx = common.WithVerification(verifyCtx, detectors.Name(job.detector))
+	var requestErrs requestErrors
+	verifyCtx = common.WithRequestErrorNotifier(verifyCtx, requestErrs.add)
+	verifyCtx, cancel := context.WithTimeout(verifyCtx, time.Second*10)
+	start := time.Now()
+	results, err := job.detector.FromData(verifyCtx, true, job.data)
+	latency := time.Since(start)
+	cancel()
+	<-e.verificationSlots
+
+	if err != nil {
+		// The secrets may still be valid, so they are reported with the error instead of being dropped.
+		logrus.WithFields(logrus.Fields{
+			"source_type": job.chunk.SourceType.String(),
+			"metadata":    job.chunk.SourceMetadata,
+		}).WithError(err).Debug("could not verify chunk")
+		results = job.results
+		if results == nil {
+			results = e.detectUnverified(ctx, job)
+		}
+		for i := range results {
+			results[i].Verified = false
+			results[i].SetVerificationError(err, string(results[i].Raw))
+		}
+	} else {
+		if job.results == nil {
+			e.metrics.resultsFound(job.detector, results)
+		}
+		requestErrs.markUnknown(results)
+	}
+	if len(results) == 0 {
+		return
+	}
+	e.metrics.verified(job.detector, results, latency)
+	if e.verificationCache != nil {
+		for i := range results {
+			// Results with unknown validity are verified again the next time they are found.
+			if results[i].VerificationError == nil {
+				e.verificationCache.Set(&results[i])
+			}
+		}
+	}
+	e.processResults(ctx, job.chunk, job.decoderChain, results, job.start)
+}
+
+// requestErrors records the first verification request of a detector that failed at the transport level. Most
+// detectors don't set a verification error when their request fails, and report the result as unverified.
+type requestErrors struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (r *requestErrors) add(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// markUnknown sets the verification error of the results that weren't verified, if a request failed, since a failed
+// request doesn't show that a secret isn't valid.
+func (r *requestErrors) markUnknown(results []detectors.Result) {
+	r.mu.Lock()
+	err := r.err
+	r.mu.Unlock()
+	if err == nil {
+		return
+	}
+	for i := range results {
+		if !results[i].Verified && results[i].VerificationError == nil {
+			results[i].SetVerificationError(err, string(results[i].Raw), string(results[i].RawV2))
+		}
+	}
+}
+
+// detectUnverified scans the data of a job that wasn't scanned before it was queued without verification, to report
+// its results when verifying them failed.
+func (e *Engine) detectUnverified(ctx context.Context, job verificationJob) []detectors.Result {
+	detectCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	results, err := job.detector.FromData(detectCtx, false, job.data)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"source_type": job.chunk.SourceType.String(),
+			"metadata":    job.chunk.SourceMetadata,
+		}).WithError(err).Error("could not scan chunk")
+		return nil
+	}
+	e.metrics.resultsFound(job.detector, results)
+	return results
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// VerificationCache stores the verification outcome of secrets so that a secret seen again during a scan, or in a
+// later scan when persisted, reuses the result instead of calling out to the provider again.
+type VerificationCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	path    string
+	entries map[string]verificationCacheEntry
+}
+
+type verificationCacheEntry struct {
+	Verified   bool              `json:"verified"`
+	ExtraData  map[string]string `json:"extra_data,omitempty"`
+	VerifiedAt time.Time         `json:"verified_at"`
+}
+
+// NewVerificationCache creates a cache whose entries expire after ttl. A ttl of 0 means entries never expire.
+// If path is not empty, entries are loaded from it if it exists and Save writes them back to it.
+func NewVerificationCache(ttl time.Duration, path string) (*VerificationCache, error) {
+	c := &VerificationCache{
+		ttl:     ttl,
+		path:    path,
+		entries: map[string]verificationCacheEntry{},
+	}
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "could not read verification cache", 0)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, errors.WrapPrefix(err, "could not parse verification cache", 0)
+	}
+	c.evictExpired()
+	logrus.Debugf("loaded %d verification cache entries from %s", len(c.entries), path)
+	return c, nil
+}
+
+// verificationCacheKey hashes the detector name and raw secret so that secrets are never persisted in plain text. The
+// other parts of secrets that have several are included, so that an AWS key ID found with a different secret is
+// verified again.
+func verificationCacheKey(r *detectors.Result) string {
+	h := sha256.New()
+	h.Write([]byte(r.DetectorName()))
+	h.Write([]byte{0})
+	h.Write(secretID(r))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *VerificationCache) expired(entry verificationCacheEntry) bool {
+	return c.ttl > 0 && time.Since(entry.VerifiedAt) > c.ttl
+}
+
+func (c *VerificationCache) evictExpired() {
+	for key, entry := range c.entries {
+		if c.expired(entry) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Get copies the cached verification status and extra data onto the result and returns true if there is an entry
+// for it that has not expired.
+func (c *VerificationCache) Get(r *detectors.Result) bool {
+	c.mu.RLock()
+	entry, ok := c.entries[verificationCacheKey(r)]
+	c.mu.RUnlock()
+	if !ok || c.expired(entry) {
+		return false
+	}
+
+	r.Verified = entry.Verified
+	if len(entry.ExtraData) > 0 {
+		r.ExtraData = make(map[string]string, len(entry.ExtraData))
+		for k, v := range entry.ExtraData {
+			r.ExtraData[k] = v
+		}
+	}
+	return true
+}
+
+// Set stores the verification status and extra data of the result.
+func (c *VerificationCache) Set(r *detectors.Result) {
+	entry := verificationCacheEntry{
+		Verified:   r.Verified,
+		VerifiedAt: time.Now(),
+	}
+	if len(r.ExtraData) > 0 {
+		entry.ExtraData = make(map[string]string, len(r.ExtraData))
+		for k, v := range r.ExtraData {
+			entry.ExtraData[k] = v
+		}
+	}
+
+	c.mu.Lock()
+	c.entries[verificationCacheKey(r)] = entry
+	c.mu.Unlock()
+}
+
+// Len returns the number of entries in the cache.
+func (c *VerificationCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Save writes the unexpired entries to the cache file. It is a no-op if the cache has no file.
+func (c *VerificationCache) Save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	c.evictExpired()
+	data, err := json.Marshal(c.entries)
+	c.mu.Unlock()
+	if err != nil {
+		return errors.WrapPrefix(err, "could not marshal verification cache", 0)
+	}
+
+	if err := common.WriteFileAtomic(c.path, data); err != nil {
+		return errors.WrapPrefix(err, "could not save verification cache", 0)
+	}
+	return nil
+}
+
+// cachedVerification copies the cached verification outcomes onto the results and returns true if every result
+// had one.
+func (c *VerificationCache) cachedVerification(results []detectors.Result) bool {
+	for i := range results {
+		if !c.Get(&results[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
+)
+
+// VerificationPolicy decides which chunks may have their secrets verified, on top of the chunk's own Verify field
+// and whether the detector was loaded with verification enabled. It lets a scan with several sources keep
+// credentials found in some of them from being sent to third parties.
+type VerificationPolicy struct {
+	// OnlySourceTypes only allows verification of chunks from these source types, if it is not empty.
+	OnlySourceTypes []sourcespb.SourceType
+	// SkipSourceTypes never allows verification of chunks from these source types.
+	SkipSourceTypes []sourcespb.SourceType
+	// SkipSourceNames never allows verification of chunks from the sources with these names.
+	SkipSourceNames []string
+}
+
+// ShouldVerify returns true if the secrets found in the chunk may be verified.
+func (p *VerificationPolicy) ShouldVerify(chunk *sources.Chunk) bool {
+	if !chunk.Verify {
+		return false
+	}
+	if p == nil {
+		return true
+	}
+	if len(p.OnlySourceTypes) > 0 && !containsSourceType(p.OnlySourceTypes, chunk.SourceType) {
+		return false
+	}
+	if containsSourceType(p.SkipSourceTypes, chunk.SourceType) {
+		return false
+	}
+	for _, name := range p.SkipSourceNames {
+		if name == chunk.SourceName {
+			return false
+		}
+	}
+	return true
+}
+
+func containsSourceType(sourceTypes []sourcespb.SourceType, sourceType sourcespb.SourceType) bool {
+	for _, t := range sourceTypes {
+		if t == sourceType {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSourceType looks up a source type by its case-insensitive name, with or without the SOURCE_TYPE_ prefix,
+// such as "github" or "SOURCE_TYPE_S3".
+func ParseSourceType(name string) (sourcespb.SourceType, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(name, "SOURCE_TYPE_") {
+		name = "SOURCE_TYPE_" + name
+	}
+	value, ok := sourcespb.SourceType_value[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown source type %q", name)
+	}
+	return sourcespb.SourceType(value), nil
+}
+
+// ParseSourceTypes looks up source types like ParseSourceType. Each name may also be a comma separated list.
+func ParseSourceTypes(names []string) ([]sourcespb.SourceType, error) {
+	var types []sourcespb.SourceType
+	for _, name := range names {
+		for _, n := range strings.Split(name, ",") {
+			if strings.TrimSpace(n) == "" {
+				continue
+			}
+			sourceType, err := ParseSourceType(n)
+			if err != nil {
+				return nil, err
+			}
+			types = append(types, sourceType)
+		}
+	}
+	return types, nil
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-errors/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/ulikunitz/xz"
+)
+
+// Limits bound the work of extracting a single file, so that archive bombs and huge backups can't stall a scan.
+type Limits struct {
+	// MaxDepth is the number of nested archives and compressed files that are extracted. A tar.gz file takes two, one
+	// for the gzip compression and one for the tar archive. Archives nested deeper are skipped, and 0 skips them all.
+	MaxDepth int
+	// MaxSize is the maximum number of bytes extracted from a file, including the data of nested archives and of the
+	// files in uncompressed tar archives. Zip files can only be read once they are complete, so they are copied to a
+	// temporary file first, and it also limits the size of each of those files.
+	MaxSize int64
+	// Timeout is the maximum time spent extracting and scanning the files in an archive.
+	Timeout time.Duration
+}
+
+// DefaultLimits are the limits used unless SetLimits is called.
+var DefaultLimits = Limits{
+	MaxDepth: 5,
+	MaxSize:  250 << 20,
+	Timeout:  5 * time.Minute,
+}
+
+var (
+	limitsMu sync.RWMutex
+	limits   = DefaultLimits
+)
+
+// SetLimits sets the limits of extracting files for all sources. The size and timeout must be positive.
+func SetLimits(l Limits) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	limits = l
+}
+
+// CurrentLimits returns the limits of extracting files.
+func CurrentLimits() Limits {
+	limitsMu.RLock()
+	defer limitsMu.RUnlock()
+	return limits
+}
+
+// FileFunc is called with the path and content of each file that HandleFile finds.
+type FileFunc func(ctx context.Context, path string, r io.Reader) error
+
+// entrySeparator separates the path of an archive from the path of a file in it, like in "backup.zip!/config.yaml".
+const entrySeparator = "!/"
+
+// entryPath returns the path of a file in an archive. Names like "./config.yaml" and "../config.yaml" are cleaned up.
+func entryPath(archive, name string) string {
+	return archive + entrySeparator + strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// HeaderSize is the number of bytes at the start of a file that IsArchive needs.
+const HeaderSize = 512
+
+type format int
+
+const (
+	formatNone format = iota
+	formatGzip
+	formatBzip2
+	formatXz
+	formatZip
+	formatTar
+)
+
+func detectFormat(header []byte) format {
+	switch {
+	case bytes.HasPrefix(header, []byte{0x1F, 0x8B}):
+		return formatGzip
+	case bytes.HasPrefix(header, []byte("BZh")):
+		return formatBzip2
+	case bytes.HasPrefix(header, []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}):
+		return formatXz
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return formatZip
+	case len(header) >= 262 && bytes.Equal(header[257:262], []byte("ustar")):
+		return formatTar
+	}
+	return formatNone
+}
+
+// IsArchive returns true if the data starts like one of the archives or compressed files that HandleFile extracts.
+func IsArchive(header []byte) bool {
+	return detectFormat(header) != formatNone
+}
+
+// HandleFile calls fn with the content of the file at path, read from r. If the file is an archive or a compressed
+// file, it's extracted as it's read, and fn is called with each file in it instead. The files in archives have the path
+// of the archive followed by their path in it, such as "backup.tar.gz!/etc/app.env", and archives in them are
+// extracted too, up to the limits.
+//
+// Files that can't be extracted, because they are corrupt or over the limits, are logged and skipped rather than
+// returned as errors, so that they don't stop the scan of the source. Errors returned by fn are returned.
+func HandleFile(ctx context.Context, path string, r io.Reader, fn FileFunc) error {
+	reader := bufio.NewReaderSize(r, HeaderSize)
+	header, _ := reader.Peek(HeaderSize)
+	if !IsArchive(header) {
+		return fn(ctx, path, reader)
+	}
+
+	l := CurrentLimits()
+	archiveCtx, cancel := context.WithTimeout(ctx, l.Timeout)
+	defer cancel()
+	e := &extraction{limits: l, remaining: l.MaxSize, fn: fn}
+	err := e.handle(archiveCtx, path, reader, 0, false)
+
+	var archiveErr *archiveError
+	switch {
+	case errors.As(err, &archiveErr):
+		log.WithError(archiveErr.err).Debugf("could not extract all files of %s", path)
+		return nil
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		log.Debugf("extracting %s took over %s, skipping the rest", path, l.Timeout)
+		return nil
+	}
+	return err
+}
+
+// extraction is the state of extracting a single file.
+type extraction struct {
+	limits Limits
+	// remaining is the number of bytes that can still be decompressed.
+	remaining int64
+	fn        FileFunc
+}
+
+// archiveError is an error reading an archive, as opposed to one returned by the FileFunc.
+type archiveError struct {
+	err error
+}
+
+func (e *archiveError) Error() string {
+	return e.err.Error()
+}
+
+var errMaxSize = errors.New("extracted data is over the size limit")
+
+// archiveReader reads data extracted from an archive. It wraps the errors of reading it, and counts the bytes it reads
+// against the size limit if count is set.
+type archiveReader struct {
+	r     io.Reader
+	e     *extraction
+	count bool
+}
+
+func (r *archiveReader) Read(p []byte) (int, error) {
+	if r.count {
+		if r.e.remaining <= 0 {
+			return 0, &archiveError{errMaxSize}
+		}
+		if int64(len(p)) > r.e.remaining {
+			p = p[:r.e.remaining]
+		}
+	}
+	n, err := r.r.Read(p)
+	if r.count {
+		r.e.remaining -= int64(n)
+	}
+	if err != nil && err != io.EOF {
+		var archiveErr *archiveError
+		if !errors.As(err, &archiveErr) {
+			err = &archiveError{err}
+		}
+	}
+	return n, err
+}
+
+func (e *extraction) decompressed(r io.Reader) io.Reader {
+	return &archiveReader{r: r, e: e, count: true}
+}
+
+// handle passes the file to the FileFunc, or extracts it if it's an archive. depth is the number of archives it's in,
+// and counted is whether the data of r already counts against the size limit.
+func (e *extraction) handle(ctx context.Context, path string, r io.Reader, depth int, counted bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	reader := bufio.NewReaderSize(r, HeaderSize)
+	header, _ := reader.Peek(HeaderSize)
+	f := detectFormat(header)
+	if f == formatNone {
+		return e.fn(ctx, path, reader)
+	}
+	if depth >= e.limits.MaxDepth {
+		log.Debugf("skipping %s, archives are nested more than %d deep", path, e.limits.MaxDepth)
+		return nil
+	}
+
+	switch f {
+	case formatGzip:
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return &archiveError{err}
+		}
+		defer gz.Close()
+		return e.handle(ctx, path, e.decompressed(gz), depth+1, true)
+	case formatBzip2:
+		return e.handle(ctx, path, e.decompressed(bzip2.NewReader(reader)), depth+1, true)
+	case formatXz:
+		xzReader, err := xz.NewReader(reader)
+		if err != nil {
+			return &archiveError{err}
+		}
+		return e.handle(ctx, path, e.decompressed(xzReader), depth+1, true)
+	case formatTar:
+		return e.handleTar(ctx, path, reader, depth, counted)
+	case formatZip:
+		return e.handleZip(ctx, path, reader, depth)
+	}
+	return nil
+}
+
+// handleTar extracts the files in a tar archive. The data of the files counts against the size limit, unless the
+// archive itself already does, like a decompressed tar.gz file.
+func (e *extraction) handleTar(ctx context.Context, path string, r io.Reader, depth int, counted bool) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return &archiveError{err}
+		}
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		entry := &archiveReader{r: tr, e: e, count: !counted}
+		if err := e.handle(ctx, entryPath(path, hdr.Name), entry, depth+1, true); err != nil {
+			return err
+		}
+	}
+}
+
+// handleZip copies the zip file to a temporary file, since the files in it can only be found from the directory at its
+// end.
+func (e *extraction) handleZip(ctx context.Context, path string, r io.Reader, depth int) error {
+	tmp, err := os.CreateTemp("", "trufflehog-zip-")
+	if err != nil {
+		return &archiveError{err}
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	size, err := io.Copy(tmp, io.LimitReader(r, e.limits.MaxSize+1))
+	if err != nil {
+		return &archiveError{err}
+	}
+	if size > e.limits.MaxSize {
+		return &archiveError{errMaxSize}
+	}
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return &archiveError{err}
+	}
+	for _, file := range zr.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		if err := e.handleZipFile(ctx, entryPath(path, file.Name), file, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *extraction) handleZipFile(ctx context.Context, path string, file *zip.File, depth int) error {
+	rc, err := file.Open()
+	if err != nil {
+		// Files of unsupported compression methods or encrypted ones don't keep the others from being scanned.
+		log.WithError(err).Debugf("could not extract %s", path)
+		return nil
+	}
+	defer rc.Close()
+	return e.handle(ctx, path, e.decompressed(rc), depth+1, true)
+}
@@ -0,0 +1,50 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/go-errors/errors"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// JSONOutput is a result in the JSON output format. It adds the verification state, and the error that prevented
+// verification if there was one, to the fields of the result.
+type JSONOutput struct {
+	detectors.ResultWithMetadata
+	VerificationState string
+	VerificationError string `json:",omitempty"`
+}
+
+func ConvertToJSON(r *detectors.ResultWithMetadata) *JSONOutput {
+	out := &JSONOutput{
+		ResultWithMetadata: *r,
+		VerificationState:  r.VerificationState().String(),
+	}
+	if r.VerificationError != nil {
+		out.VerificationError = r.VerificationError.Error()
+	}
+	return out
+}
+
+// jsonSink writes results in the JSON format, one per line.
+type jsonSink struct {
+	fileSink
+}
+
+func (s *jsonSink) Write(_ context.Context, r *detectors.ResultWithMetadata) error {
+	return writeJSONLine(s.w, ConvertToJSON(r))
+}
+
+func writeJSONLine(w io.Writer, v interface{}) error {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return errors.WrapPrefix(err, "could not marshal result", 0)
+	}
+	if _, err := w.Write(append(out, '\n')); err != nil {
+		return errors.WrapPrefix(err, "could not write result", 0)
+	}
+	return nil
+}
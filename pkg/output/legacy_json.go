@@ -1,6 +1,7 @@
 package output
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/url"
@@ -64,6 +65,16 @@ func ConvertToLegacyJSON(r *detectors.ResultWithMetadata, repoPath string) *Lega
 	return output
 }
 
+// legacyJSONSink writes results in the pre-v3.0 JSON format, one per line.
+type legacyJSONSink struct {
+	fileSink
+	repoPath string
+}
+
+func (s *legacyJSONSink) Write(_ context.Context, r *detectors.ResultWithMetadata) error {
+	return writeJSONLine(s.w, ConvertToLegacyJSON(r, s.repoPath))
+}
+
 // BranchHeads creates a map of branch names to their head commit. This can be used to find if a commit is an ancestor
 // of a branch head.
 func BranchHeads(repo *gogit.Repository) (map[string]*object.Commit, error) {
@@ -1,12 +1,17 @@
 package output
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/go-errors/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/detectorspb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 )
 
@@ -39,9 +44,28 @@ var (
 		text:   whitePrinter,
 		data:   whitePrinter,
 	}
+
+	noColorPrinter = func() *color.Color {
+		c := color.New()
+		c.DisableColor()
+		return c
+	}()
+
+	noColor = Printers{
+		header: noColorPrinter,
+		text:   noColorPrinter,
+		data:   noColorPrinter,
+	}
 )
 
 func PrintPlainOutput(r *detectors.ResultWithMetadata, faintWhiteOnly bool) {
+	if err := writePlainOutput(color.Output, r, faintWhiteOnly, true); err != nil {
+		logrus.WithError(err).Fatal("could not marshal result")
+	}
+}
+
+// writePlainOutput writes the result in the plain format, in color unless colored is false.
+func writePlainOutput(w io.Writer, r *detectors.ResultWithMetadata, faintWhiteOnly, colored bool) error {
 	out := outputFormat{
 		DetectorType: r.Result.DetectorType.String(),
 		Verified:     r.Result.Verified,
@@ -51,36 +75,67 @@ func PrintPlainOutput(r *detectors.ResultWithMetadata, faintWhiteOnly bool) {
 
 	meta, err := structToMap(out.MetaData.Data)
 	if err != nil {
-		logrus.WithError(err).Fatal("could not marshal result")
+		return err
 	}
 
-	var verifiedStatus, icon string
+	var header string
 	var printers Printers
 
-	if out.Verified {
+	switch r.Result.VerificationState() {
+	case detectors.StateVerified:
 		printers = verified
-		verifiedStatus = "verified"
-		icon = ""
-	} else {
+		header = "Found verified result 🐷🔑"
+	case detectors.StateUnknown:
 		printers = unverified
-		verifiedStatus = "unverified"
-		icon = "❓"
+		header = "Found result that could not be verified 🐷🔑❔"
+	default:
+		printers = unverified
+		header = "Found unverified result 🐷🔑❓"
 	}
 
 	if faintWhiteOnly {
 		printers = faint
 	}
+	if !colored {
+		printers = noColor
+	}
 
-	printers.header.Printf("Found %s result 🐷🔑%s\n", verifiedStatus, icon)
-	printers.text.Printf("Detector Type: %s\n", out.DetectorType)
-	printers.text.Printf("Raw result: %s\n", printers.data.Sprint(out.Raw))
+	printers.header.Fprintf(w, "%s\n", header)
+	printers.text.Fprintf(w, "Detector Type: %s\n", out.DetectorType)
+	if r.Result.DetectorType == detectorspb.DetectorType_CustomRegex {
+		printers.text.Fprintf(w, "Detector Name: %s\n", r.Result.ExtraData["name"])
+	}
+	if len(r.DecoderChain) > 0 {
+		printers.text.Fprintf(w, "Decoder Chain: %s\n", strings.Join(r.DecoderChain, " -> "))
+	}
+	printers.text.Fprintf(w, "Raw result: %s\n", printers.data.Sprint(out.Raw))
+	if r.Result.VerificationError != nil {
+		printers.text.Fprintf(w, "Verification Error: %s\n", r.Result.VerificationError)
+	}
 
 	for _, data := range meta {
 		for k, v := range data {
-			printers.text.Printf("%s: %v\n", strings.Title(k), v)
+			printers.text.Fprintf(w, "%s: %v\n", strings.Title(k), v)
 		}
 	}
-	printers.text.Printf("\n")
+	printers.text.Fprintf(w, "\n")
+	return nil
+}
+
+// plainSink writes results in the plain format.
+type plainSink struct {
+	fileSink
+}
+
+func (s *plainSink) Write(_ context.Context, r *detectors.ResultWithMetadata) error {
+	var buf bytes.Buffer
+	if err := writePlainOutput(&buf, r, false, s.colored()); err != nil {
+		return errors.WrapPrefix(err, "could not format result", 0)
+	}
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		return errors.WrapPrefix(err, "could not write result", 0)
+	}
+	return nil
 }
 
 func structToMap(obj interface{}) (m map[string]map[string]interface{}, err error) {
@@ -0,0 +1,189 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-errors/errors"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/detectors"
+)
+
+// Sink writes results somewhere, such as the terminal or a file. Write isn't called concurrently.
+type Sink interface {
+	// Open prepares the sink before the first result is written.
+	Open(ctx context.Context) error
+	// Write writes a result.
+	Write(ctx context.Context, r *detectors.ResultWithMetadata) error
+	// Close flushes the results written so far and releases the sink's resources.
+	Close() error
+}
+
+// SinkConfig configures a sink created by a SinkFactory.
+type SinkConfig struct {
+	// Path is where the sink writes to, such as a file. Sinks that write to a stream write to standard output if it
+	// is empty or "-".
+	Path string
+	// RepoPath is the local path of the git repository that was scanned, if any.
+	RepoPath string
+}
+
+// SinkFactory creates a sink.
+type SinkFactory func(config SinkConfig) (Sink, error)
+
+var (
+	sinksMu sync.RWMutex
+	sinks   = map[string]SinkFactory{}
+)
+
+// Built-in sinks.
+const (
+	SinkPlain      = "plain"
+	SinkJSON       = "json"
+	SinkJSONLegacy = "json-legacy"
+)
+
+func init() {
+	RegisterSink(SinkPlain, func(config SinkConfig) (Sink, error) {
+		return &plainSink{fileSink: fileSink{path: config.Path}}, nil
+	})
+	RegisterSink(SinkJSON, func(config SinkConfig) (Sink, error) {
+		return &jsonSink{fileSink: fileSink{path: config.Path}}, nil
+	})
+	RegisterSink(SinkJSONLegacy, func(config SinkConfig) (Sink, error) {
+		return &legacyJSONSink{fileSink: fileSink{path: config.Path}, repoPath: config.RepoPath}, nil
+	})
+}
+
+// RegisterSink makes a sink available by name, so it can be created with NewSink. It panics if a sink with the same
+// name is already registered, or if the factory is nil.
+func RegisterSink(name string, factory SinkFactory) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	if factory == nil {
+		panic("output: sink factory is nil")
+	}
+	if _, ok := sinks[name]; ok {
+		panic("output: RegisterSink called twice for sink " + name)
+	}
+	sinks[name] = factory
+}
+
+// SinkNames returns the names of the registered sinks.
+func SinkNames() []string {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	names := make([]string, 0, len(sinks))
+	for name := range sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewSink creates the sink registered under name.
+func NewSink(name string, config SinkConfig) (Sink, error) {
+	sinksMu.RLock()
+	factory, ok := sinks[name]
+	sinksMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unknown sink %q, must be one of %s", name, strings.Join(SinkNames(), ", "))
+	}
+	sink, err := factory(config)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, fmt.Sprintf("could not create sink %q", name), 0)
+	}
+	return sink, nil
+}
+
+// ParseSink creates a sink from a specification of its name and optional path, such as "plain" or
+// "json=results.json".
+func ParseSink(spec string, config SinkConfig) (Sink, error) {
+	name, path := spec, ""
+	if i := strings.Index(spec, "="); i >= 0 {
+		name, path = spec[:i], spec[i+1:]
+	}
+	config.Path = path
+	return NewSink(name, config)
+}
+
+// MultiSink writes every result to each of its sinks.
+type MultiSink []Sink
+
+// Ensure the MultiSink satisfies the interface at compile time.
+var _ Sink = MultiSink(nil)
+
+// Open opens every sink. If one fails, those that were already opened are closed.
+func (m MultiSink) Open(ctx context.Context) error {
+	for i, sink := range m {
+		if err := sink.Open(ctx); err != nil {
+			for _, opened := range m[:i] {
+				_ = opened.Close()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Write writes the result to every sink, even if writing to one of them fails.
+func (m MultiSink) Write(ctx context.Context, r *detectors.ResultWithMetadata) error {
+	var firstErr error
+	for _, sink := range m {
+		if err := sink.Write(ctx, r); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close closes every sink and returns the first error.
+func (m MultiSink) Close() error {
+	var firstErr error
+	for _, sink := range m {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// fileSink writes to the file at path, or to standard output.
+type fileSink struct {
+	path string
+	w    io.Writer
+	file *os.File
+}
+
+func (s *fileSink) Open(context.Context) error {
+	if s.path == "" || s.path == "-" {
+		s.w = os.Stdout
+		return nil
+	}
+	file, err := os.Create(s.path)
+	if err != nil {
+		return errors.WrapPrefix(err, "could not open output file", 0)
+	}
+	s.file, s.w = file, file
+	return nil
+}
+
+func (s *fileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	if err := s.file.Close(); err != nil {
+		return errors.WrapPrefix(err, "could not close output file", 0)
+	}
+	return nil
+}
+
+// colored returns true if the sink writes to the terminal.
+func (s *fileSink) colored() bool {
+	return s.file == nil
+}
@@ -866,6 +866,13 @@ const (
 	DetectorType_Codeclimate                   DetectorType = 844
 	DetectorType_Codemagic                     DetectorType = 845
 	DetectorType_Vbout                         DetectorType = 846
+	DetectorType_Nightfall                     DetectorType = 847
+	DetectorType_FlightLabs                    DetectorType = 848
+	DetectorType_SpeechTextAI                  DetectorType = 849
+	DetectorType_PollsAPI                      DetectorType = 850
+	DetectorType_SimFin                        DetectorType = 851
+	DetectorType_CustomRegex                   DetectorType = 852
+	DetectorType_Entropy                       DetectorType = 853
 )
 
 // Enum value maps for DetectorType.
@@ -1714,6 +1721,13 @@ var (
 		844: "Codeclimate",
 		845: "Codemagic",
 		846: "Vbout",
+		847: "Nightfall",
+		848: "FlightLabs",
+		849: "SpeechTextAI",
+		850: "PollsAPI",
+		851: "SimFin",
+		852: "CustomRegex",
+		853: "Entropy",
 	}
 	DetectorType_value = map[string]int32{
 		"Alibaba":                       0,
@@ -2559,6 +2573,13 @@ var (
 		"Codeclimate":                   844,
 		"Codemagic":                     845,
 		"Vbout":                         846,
+		"Nightfall":                     847,
+		"FlightLabs":                    848,
+		"SpeechTextAI":                  849,
+		"PollsAPI":                      850,
+		"SimFin":                        851,
+		"CustomRegex":                   852,
+		"Entropy":                       853,
 	}
 )
 
@@ -2898,7 +2919,7 @@ var file_detectors_proto_rawDesc = []byte{
 	0x75, 0x73, 0x65, 0x72, 0x12, 0x34, 0x0a, 0x16, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b,
 	0x65, 0x79, 0x5f, 0x66, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x18, 0x02,
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x14, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x46,
-	0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x2a, 0x94, 0x6a, 0x0a, 0x0c, 0x44,
+	0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x2a, 0x84, 0x6b, 0x0a, 0x0c, 0x44,
 	0x65, 0x74, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x41,
 	0x6c, 0x69, 0x62, 0x61, 0x62, 0x61, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x41, 0x4d, 0x51, 0x50,
 	0x10, 0x01, 0x12, 0x07, 0x0a, 0x03, 0x41, 0x57, 0x53, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x41,
@@ -3748,6 +3769,13 @@ var file_detectors_proto_rawDesc = []byte{
 	0x10, 0xcb, 0x06, 0x12, 0x10, 0x0a, 0x0b, 0x43, 0x6f, 0x64, 0x65, 0x63, 0x6c, 0x69, 0x6d, 0x61,
 	0x74, 0x65, 0x10, 0xcc, 0x06, 0x12, 0x0e, 0x0a, 0x09, 0x43, 0x6f, 0x64, 0x65, 0x6d, 0x61, 0x67,
 	0x69, 0x63, 0x10, 0xcd, 0x06, 0x12, 0x0a, 0x0a, 0x05, 0x56, 0x62, 0x6f, 0x75, 0x74, 0x10, 0xce,
+	0x06, 0x12, 0x0e, 0x0a, 0x09, 0x4e, 0x69, 0x67, 0x68, 0x74, 0x66, 0x61, 0x6c, 0x6c, 0x10, 0xcf,
+	0x06, 0x12, 0x0f, 0x0a, 0x0a, 0x46, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x4c, 0x61, 0x62, 0x73, 0x10,
+	0xd0, 0x06, 0x12, 0x11, 0x0a, 0x0c, 0x53, 0x70, 0x65, 0x65, 0x63, 0x68, 0x54, 0x65, 0x78, 0x74,
+	0x41, 0x49, 0x10, 0xd1, 0x06, 0x12, 0x0d, 0x0a, 0x08, 0x50, 0x6f, 0x6c, 0x6c, 0x73, 0x41, 0x50,
+	0x49, 0x10, 0xd2, 0x06, 0x12, 0x0b, 0x0a, 0x06, 0x53, 0x69, 0x6d, 0x46, 0x69, 0x6e, 0x10, 0xd3,
+	0x06, 0x12, 0x10, 0x0a, 0x0b, 0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x52, 0x65, 0x67, 0x65, 0x78,
+	0x10, 0xd4, 0x06, 0x12, 0x0c, 0x0a, 0x07, 0x45, 0x6e, 0x74, 0x72, 0x6f, 0x70, 0x79, 0x10, 0xd5,
 	0x06, 0x42, 0x3d, 0x5a, 0x3b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
 	0x74, 0x72, 0x75, 0x66, 0x66, 0x6c, 0x65, 0x73, 0x65, 0x63, 0x75, 0x72, 0x69, 0x74, 0x79, 0x2f,
 	0x74, 0x72, 0x75, 0x66, 0x66, 0x6c, 0x65, 0x68, 0x6f, 0x67, 0x2f, 0x76, 0x33, 0x2f, 0x70, 0x6b,
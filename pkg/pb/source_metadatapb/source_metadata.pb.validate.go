@@ -185,6 +185,8 @@ func (m *Bitbucket) validate(all bool) error {
 
 	// no validation rules for Line
 
+	// no validation rules for Column
+
 	if len(errors) > 0 {
 		return BitbucketMultiError(errors)
 	}
@@ -848,6 +850,12 @@ func (m *Filesystem) validate(all bool) error {
 
 	// no validation rules for Email
 
+	// no validation rules for Line
+
+	// no validation rules for Column
+
+	// no validation rules for Offset
+
 	if len(errors) > 0 {
 		return FilesystemMultiError(errors)
 	}
@@ -958,6 +966,8 @@ func (m *Git) validate(all bool) error {
 
 	// no validation rules for Line
 
+	// no validation rules for Column
+
 	if len(errors) > 0 {
 		return GitMultiError(errors)
 	}
@@ -1072,6 +1082,8 @@ func (m *Github) validate(all bool) error {
 
 	// no validation rules for Line
 
+	// no validation rules for Column
+
 	if len(errors) > 0 {
 		return GithubMultiError(errors)
 	}
@@ -1184,6 +1196,8 @@ func (m *Gitlab) validate(all bool) error {
 
 	// no validation rules for Line
 
+	// no validation rules for Column
+
 	if len(errors) > 0 {
 		return GitlabMultiError(errors)
 	}
@@ -1724,6 +1738,12 @@ func (m *S3) validate(all bool) error {
 
 	// no validation rules for Timestamp
 
+	// no validation rules for Line
+
+	// no validation rules for Column
+
+	// no validation rules for Offset
+
 	if len(errors) > 0 {
 		return S3MultiError(errors)
 	}
@@ -1946,6 +1966,8 @@ func (m *Gerrit) validate(all bool) error {
 
 	// no validation rules for Line
 
+	// no validation rules for Column
+
 	if len(errors) > 0 {
 		return GerritMultiError(errors)
 	}
@@ -0,0 +1,96 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+)
+
+const (
+	// ChunkSize is the maximum size of the data a chunk starts with. It is mainly driven by our largest credential
+	// size, which is GCP @ ~2.25KB.
+	ChunkSize = 10 * 1024 // 10KB
+	// PeekSize is the maximum size of the data from the next chunk that is appended to a chunk. Having a peek size
+	// larger than our largest credential ensures that secrets on chunk boundaries are found.
+	PeekSize = 3 * 1024 // 3KB
+	// TotalChunkSize is the maximum size of a chunk's data.
+	TotalChunkSize = ChunkSize + PeekSize
+)
+
+// ChunkPosition is where a chunk's data starts in the data that was split.
+type ChunkPosition struct {
+	// Offset is the number of bytes before the chunk.
+	Offset int64
+	// Line is the line the chunk starts on, starting at 1.
+	Line int64
+}
+
+// ChunkFunc is called with the data of each chunk and its position.
+type ChunkFunc func(data []byte, pos ChunkPosition) error
+
+// ChunkReader reads r in chunks of at most ChunkSize bytes, each followed by up to PeekSize bytes of the next chunk,
+// and calls fn with each of them in order. Only one chunk is held in memory at a time, however large r is. It stops
+// at the first error returned by fn, or when the context is cancelled.
+func ChunkReader(ctx context.Context, r io.Reader, fn ChunkFunc) error {
+	reader := bufio.NewReaderSize(r, ChunkSize)
+	pos := ChunkPosition{Line: 1}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		buf := make([]byte, ChunkSize, TotalChunkSize)
+		n, err := io.ReadFull(reader, buf)
+		if n > 0 {
+			// We are peeking in case a secret exists in our chunk boundaries. One more byte than needed is peeked to
+			// find out whether there is anything left after the peek.
+			peekData, peekErr := reader.Peek(PeekSize + 1)
+			last := len(peekData) <= PeekSize
+			if !last {
+				peekData = peekData[:PeekSize]
+			}
+			if fnErr := fn(append(buf[:n], peekData...), pos); fnErr != nil {
+				return fnErr
+			}
+			pos.Offset += int64(n)
+			pos.Line += int64(bytes.Count(buf[:n], []byte("\n")))
+			// The rest of the data fit in the peek, so there is nothing left that hasn't been passed to fn.
+			if last && errors.Is(peekErr, io.EOF) {
+				return nil
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ChunkBytes splits data the same way as ChunkReader. Data that fits in a single chunk is passed to fn as is.
+func ChunkBytes(ctx context.Context, data []byte, fn ChunkFunc) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if len(data) <= TotalChunkSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(data, ChunkPosition{Line: 1})
+	}
+	return ChunkReader(ctx, bytes.NewReader(data), fn)
+}
+
+// SendChunk sends the chunk to chunksChan, or returns the error of the context if it is cancelled first, so sources
+// don't stay blocked on a scan that was stopped.
+func SendChunk(ctx context.Context, chunksChan chan *Chunk, chunk *Chunk) error {
+	select {
+	case chunksChan <- chunk:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,13 +1,13 @@
 package filesystem
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/go-errors/errors"
 	log "github.com/sirupsen/logrus"
@@ -15,6 +15,7 @@ import (
 	"google.golang.org/protobuf/types/known/anypb"
 
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
@@ -22,12 +23,19 @@ import (
 )
 
 const (
-	// These buffer sizes are mainly driven by our largest credential size, which is GCP @ ~2.25KB.
-	// Having a peek size larger than that ensures that we have complete credential coverage in our chunks.
-	BufferSize = 10 * 1024 // 10KB
-	PeekSize   = 3 * 1024  // 3KB
+	// BufferSize is the maximum size of the data a chunk starts with.
+	//
+	// Deprecated: Use sources.ChunkSize.
+	BufferSize = sources.ChunkSize
+	// PeekSize is the maximum size of the data from the next chunk that is appended to a chunk.
+	//
+	// Deprecated: Use sources.PeekSize.
+	PeekSize = sources.PeekSize
 )
 
+// errSkipFile stops chunking a file that shouldn't be scanned.
+var errSkipFile = errors.New("skip file")
+
 type Source struct {
 	name     string
 	sourceId int64
@@ -81,18 +89,22 @@ func (s *Source) Init(aCtx context.Context, name string, jobId, sourceId int64,
 func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) error {
 	for i, path := range s.paths {
 		s.SetProgressComplete(i, len(s.paths), fmt.Sprintf("Path: %s", path), "")
+		unit := path
+		if s.UnitCompleted(unit) {
+			log.Debugf("skipping path %s, it was scanned completely before the scan was resumed", unit)
+			continue
+		}
+		resumeAfter := s.UnitPosition(unit)
 
 		cleanPath := filepath.Clean(path)
-		done := false
-		go func() {
-			<-ctx.Done()
-			done = true
-		}()
 
 		err := fs.WalkDir(os.DirFS(cleanPath), ".", func(relativePath string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return nil
 			}
+			if resumeAfter != "" && !walkOrderLess(resumeAfter, relativePath) {
+				return nil
+			}
 
 			path := filepath.Join(cleanPath, relativePath)
 
@@ -112,69 +124,66 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 			}
 			defer inputFile.Close()
 
-			reader := bufio.NewReaderSize(bufio.NewReader(inputFile), BufferSize)
-			firstChunk := true
-			for {
-				if done {
-					return nil
-				}
-
-				end := BufferSize
-				buf := make([]byte, BufferSize)
-				n, err := reader.Read(buf)
-
-				if n < BufferSize {
-					end = n
-				}
-
-				if end > 0 {
-					data := buf[0:end]
-
-					if firstChunk {
-						firstChunk = false
-						if common.SkipFile(path, data) {
-							return nil
-						}
+			err = handlers.HandleFile(ctx, path, inputFile, func(ctx context.Context, file string, r io.Reader) error {
+				err := sources.ChunkReader(ctx, r, func(data []byte, pos sources.ChunkPosition) error {
+					if pos.Offset == 0 && common.SkipFile(file, data) {
+						return errSkipFile
 					}
-
-					// We are peeking in case a secret exists in our chunk boundaries,
-					// but we never care if we've run into a peek error.
-					peekData, _ := reader.Peek(PeekSize)
-					chunksChan <- &sources.Chunk{
+					return sources.SendChunk(ctx, chunksChan, &sources.Chunk{
 						SourceType: s.Type(),
 						SourceName: s.name,
 						SourceID:   s.SourceID(),
-						Data:       append(data, peekData...),
+						Data:       data,
 						SourceMetadata: &source_metadatapb.MetaData{
 							Data: &source_metadatapb.MetaData_Filesystem{
 								Filesystem: &source_metadatapb.Filesystem{
-									File: sanitizer.UTF8(path),
+									File:   sanitizer.UTF8(file),
+									Line:   pos.Line,
+									Offset: pos.Offset,
 								},
 							},
 						},
 						Verify: s.verify,
-					}
-				}
-
-				// io.EOF can be emmitted when 0<n<buffer size
-				if err != nil {
-					if errors.Is(err, io.EOF) {
-						return nil
-					} else {
-						return err
-					}
+					})
+				})
+				// Skipping a file in an archive doesn't skip the rest of the archive.
+				if errors.Is(err, errSkipFile) {
+					return nil
 				}
+				return err
+			})
+			if err == nil {
+				s.SetUnitPosition(unit, relativePath)
+				return nil
+			}
+			if errors.Is(err, ctx.Err()) {
+				return nil
 			}
+			return err
 		})
 
 		if err != nil && err != io.EOF {
 			return errors.New(err)
 		}
 
-		if done {
+		// An interrupted scan continues after the last file it sent.
+		if ctx.Err() != nil {
 			return nil
 		}
-
+		s.CompleteUnit(unit)
 	}
 	return nil
 }
+
+// walkOrderLess returns true if fs.WalkDir visits the slash separated path a before b. Directory entries are
+// visited in lexical order, and the contents of a directory before its next sibling.
+func walkOrderLess(a, b string) bool {
+	aParts := strings.Split(a, "/")
+	bParts := strings.Split(b, "/")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		if aParts[i] != bParts[i] {
+			return aParts[i] < bParts[i]
+		}
+	}
+	return len(aParts) < len(bParts)
+}
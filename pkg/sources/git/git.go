@@ -1,6 +1,7 @@
 package git
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
@@ -25,6 +26,8 @@ import (
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 
+	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
@@ -131,7 +134,7 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 
 		for i, repoURI := range s.conn.Repositories {
 			s.SetProgressComplete(i, len(s.conn.Repositories), fmt.Sprintf("Repo: %s", repoURI), "")
-			if len(repoURI) == 0 {
+			if len(repoURI) == 0 || s.UnitCompleted(repoURI) {
 				continue
 			}
 			path, repo, err := CloneRepoUsingToken(token, repoURI, user)
@@ -139,15 +142,18 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 			if err != nil {
 				return err
 			}
-			err = s.git.ScanRepo(ctx, repo, path, NewScanOptions(), chunksChan)
+			err = s.git.ScanRepo(ctx, repo, path, NewResumableScanOptions(&s.Progress, repoURI), chunksChan)
 			if err != nil {
 				return err
 			}
+			if !common.IsDone(ctx) {
+				s.CompleteUnit(repoURI)
+			}
 		}
 	case *sourcespb.Git_Unauthenticated:
 		for i, repoURI := range s.conn.Repositories {
 			s.SetProgressComplete(i, len(s.conn.Repositories), fmt.Sprintf("Repo: %s", repoURI), "")
-			if len(repoURI) == 0 {
+			if len(repoURI) == 0 || s.UnitCompleted(repoURI) {
 				continue
 			}
 			path, repo, err := CloneRepoUsingUnauthenticated(repoURI)
@@ -155,10 +161,13 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 			if err != nil {
 				return err
 			}
-			err = s.git.ScanRepo(ctx, repo, path, NewScanOptions(), chunksChan)
+			err = s.git.ScanRepo(ctx, repo, path, NewResumableScanOptions(&s.Progress, repoURI), chunksChan)
 			if err != nil {
 				return err
 			}
+			if !common.IsDone(ctx) {
+				s.CompleteUnit(repoURI)
+			}
 		}
 	default:
 		return errors.New("invalid connection type for git source")
@@ -167,7 +176,7 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 	for i, u := range s.conn.Directories {
 		s.SetProgressComplete(i, len(s.conn.Repositories), fmt.Sprintf("Repo: %s", u), "")
 
-		if len(u) == 0 {
+		if len(u) == 0 || s.UnitCompleted(u) {
 			continue
 		}
 		if !strings.HasSuffix(u, "git") {
@@ -180,11 +189,14 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 				defer os.RemoveAll(u)
 			}
 
-			err = s.git.ScanRepo(ctx, repo, u, NewScanOptions(), chunksChan)
+			err = s.git.ScanRepo(ctx, repo, u, NewResumableScanOptions(&s.Progress, u), chunksChan)
 			if err != nil {
 				return err
 
 			}
+			if !common.IsDone(ctx) {
+				s.CompleteUnit(u)
+			}
 		}
 
 	}
@@ -262,7 +274,7 @@ func GitCmdCheck() error {
 	return nil
 }
 
-func (s *Git) ScanCommits(repo *git.Repository, path string, scanOptions *ScanOptions, chunksChan chan *sources.Chunk) error {
+func (s *Git) ScanCommits(ctx context.Context, repo *git.Repository, path string, scanOptions *ScanOptions, chunksChan chan *sources.Chunk) error {
 	if err := GitCmdCheck(); err != nil {
 		return err
 	}
@@ -294,7 +306,19 @@ func (s *Git) ScanCommits(repo *git.Repository, path string, scanOptions *ScanOp
 
 	var depth int64
 	var reachedBase = false
+	// When resuming, files are skipped until the commit after the one the previous scan finished with.
+	skipping := scanOptions.ResumeAfter != ""
+	var reachedResume bool
+	var lastCommit string
 	for file := range fileChan {
+		if common.IsDone(ctx) {
+			// Drain the remaining files so the git log parser can exit.
+			go func() {
+				for range fileChan {
+				}
+			}()
+			return ctx.Err()
+		}
 		if file == nil || file.PatchHeader == nil {
 			log.Debugf("file missing patch header, skipping")
 			continue
@@ -314,6 +338,22 @@ func (s *Git) ScanCommits(repo *git.Repository, path string, scanOptions *ScanOp
 				reachedBase = true
 			}
 		}
+		if skipping {
+			if file.PatchHeader.SHA == scanOptions.ResumeAfter {
+				reachedResume = true
+				continue
+			}
+			if !reachedResume {
+				continue
+			}
+			skipping = false
+		}
+		if file.PatchHeader.SHA != lastCommit {
+			if lastCommit != "" && scanOptions.CommitScanned != nil {
+				scanOptions.CommitScanned(lastCommit)
+			}
+			lastCommit = file.PatchHeader.SHA
+		}
 		if !scanOptions.Filter.Pass(file.NewName) {
 			continue
 		}
@@ -331,6 +371,14 @@ func (s *Git) ScanCommits(repo *git.Repository, path string, scanOptions *ScanOp
 			when = file.PatchHeader.AuthorDate.String()
 		}
 
+		// The diffs of binary files don't have their content, so archives are read from the commit instead.
+		if file.IsBinary && !file.IsDelete {
+			if err := s.scanArchive(ctx, repo, fileName, email, hash, when, urlMetadata, chunksChan); err != nil {
+				log.WithError(err).Debugf("could not scan binary file %s in commit %s", fileName, hash)
+			}
+			continue
+		}
+
 		for _, frag := range file.TextFragments {
 			newLines := bytes.Buffer{}
 			newLineNumber := frag.NewPosition
@@ -340,21 +388,74 @@ func (s *Git) ScanCommits(repo *git.Repository, path string, scanOptions *ScanOp
 				}
 			}
 			log.WithField("fragment", newLines.String()).Trace("detecting fragment")
-			metadata := s.sourceMetadataFunc(fileName, email, hash, when, urlMetadata, newLineNumber)
-			chunksChan <- &sources.Chunk{
-				SourceName:     s.sourceName,
-				SourceID:       s.sourceID,
-				SourceType:     s.sourceType,
-				SourceMetadata: metadata,
-				Data:           newLines.Bytes(),
-				Verify:         s.verify,
+			fragment := newLines.Bytes()
+			err := sources.ChunkBytes(ctx, fragment, func(data []byte, pos sources.ChunkPosition) error {
+				// Large fragments are split, so each chunk starts on a later line of the fragment.
+				lineNumber := newLineNumber + pos.Line - 1
+				metadata := s.sourceMetadataFunc(fileName, email, hash, when, urlMetadata, lineNumber)
+				return sources.SendChunk(ctx, chunksChan, &sources.Chunk{
+					SourceName:     s.sourceName,
+					SourceID:       s.sourceID,
+					SourceType:     s.sourceType,
+					SourceMetadata: metadata,
+					Data:           data,
+					Verify:         s.verify,
+				})
+			})
+			if err != nil {
+				// The scan was cancelled; the next file drains the parser.
+				break
 			}
 		}
 	}
+	if common.IsDone(ctx) {
+		return ctx.Err()
+	}
+	if skipping && !reachedResume {
+		log.Warnf("could not find commit %s to resume the scan from, nothing was scanned", scanOptions.ResumeAfter)
+	}
+	if lastCommit != "" && scanOptions.CommitScanned != nil {
+		scanOptions.CommitScanned(lastCommit)
+	}
 	return nil
 }
 
-func (s *Git) ScanUnstaged(repo *git.Repository, scanOptions *ScanOptions, chunksChan chan *sources.Chunk) error {
+// scanArchive scans the files in the archive that the commit added or changed. Other binary files are skipped.
+func (s *Git) scanArchive(ctx context.Context, repo *git.Repository, fileName, email, hash, when, urlMetadata string, chunksChan chan *sources.Chunk) error {
+	commit, err := repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return err
+	}
+	file, err := commit.File(fileName)
+	if err != nil {
+		return err
+	}
+	blob, err := file.Reader()
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	reader := bufio.NewReader(blob)
+	header, _ := reader.Peek(handlers.HeaderSize)
+	if !handlers.IsArchive(header) {
+		return nil
+	}
+	return handlers.HandleFile(ctx, fileName, reader, func(ctx context.Context, path string, r io.Reader) error {
+		return sources.ChunkReader(ctx, r, func(data []byte, pos sources.ChunkPosition) error {
+			return sources.SendChunk(ctx, chunksChan, &sources.Chunk{
+				SourceName:     s.sourceName,
+				SourceID:       s.sourceID,
+				SourceType:     s.sourceType,
+				SourceMetadata: s.sourceMetadataFunc(path, email, hash, when, urlMetadata, pos.Line),
+				Data:           data,
+				Verify:         s.verify,
+			})
+		})
+	})
+}
+
+func (s *Git) ScanUnstaged(ctx context.Context, repo *git.Repository, scanOptions *ScanOptions, chunksChan chan *sources.Chunk) error {
 	// get the URL metadata for reporting (may be empty)
 	urlMetadata := getSafeRemoteURL(repo, "origin")
 
@@ -373,42 +474,49 @@ func (s *Git) ScanUnstaged(repo *git.Repository, scanOptions *ScanOptions, chunk
 			return err
 		}
 		for fh := range status {
+			if common.IsDone(ctx) {
+				return ctx.Err()
+			}
 			if !scanOptions.Filter.Pass(fh) {
 				continue
 			}
-			metadata := s.sourceMetadataFunc(
-				fh, "unstaged", "unstaged", time.Now().String(), urlMetadata, 0,
-			)
-
-			fileBuf := bytes.NewBuffer(nil)
 			fileHandle, err := wt.Filesystem.Open(fh)
 			if err != nil {
 				continue
 			}
-			defer fileHandle.Close()
-			_, err = io.Copy(fileBuf, fileHandle)
-			if err != nil {
-				continue
+			err = handlers.HandleFile(ctx, fh, fileHandle, func(ctx context.Context, path string, r io.Reader) error {
+				return sources.ChunkReader(ctx, r, func(data []byte, pos sources.ChunkPosition) error {
+					metadata := s.sourceMetadataFunc(
+						path, "unstaged", "unstaged", time.Now().String(), urlMetadata, pos.Line,
+					)
+					return sources.SendChunk(ctx, chunksChan, &sources.Chunk{
+						SourceType:     s.sourceType,
+						SourceName:     s.sourceName,
+						SourceID:       s.sourceID,
+						Data:           data,
+						SourceMetadata: metadata,
+						Verify:         s.verify,
+					})
+				})
+			})
+			fileHandle.Close()
+			if err != nil && errors.Is(err, ctx.Err()) {
+				return err
 			}
-			chunksChan <- &sources.Chunk{
-				SourceType:     s.sourceType,
-				SourceName:     s.sourceName,
-				SourceID:       s.sourceID,
-				Data:           fileBuf.Bytes(),
-				SourceMetadata: metadata,
-				Verify:         s.verify,
+			if err != nil {
+				log.WithError(err).Debugf("could not read unstaged file %s", fh)
 			}
 		}
 	}
 	return nil
 }
 
-func (s *Git) ScanRepo(_ context.Context, repo *git.Repository, repoPath string, scanOptions *ScanOptions, chunksChan chan *sources.Chunk) error {
+func (s *Git) ScanRepo(ctx context.Context, repo *git.Repository, repoPath string, scanOptions *ScanOptions, chunksChan chan *sources.Chunk) error {
 	start := time.Now().UnixNano()
-	if err := s.ScanCommits(repo, repoPath, scanOptions, chunksChan); err != nil {
+	if err := s.ScanCommits(ctx, repo, repoPath, scanOptions, chunksChan); err != nil {
 		return err
 	}
-	if err := s.ScanUnstaged(repo, scanOptions, chunksChan); err != nil {
+	if err := s.ScanUnstaged(ctx, repo, scanOptions, chunksChan); err != nil {
 		// https://github.com/src-d/go-git/issues/879
 		if strings.Contains(err.Error(), "object not found") {
 			log.WithError(err).Error("known issue: probably caused by a dangling reference in the repo")
@@ -3,6 +3,7 @@ package git
 import (
 	"github.com/go-git/go-git/v5"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/sources"
 )
 
 type ScanOptions struct {
@@ -11,6 +12,10 @@ type ScanOptions struct {
 	HeadHash   string
 	MaxDepth   int64
 	LogOptions *git.LogOptions
+	// ResumeAfter skips the commits up to and including this one, in the order they are scanned.
+	ResumeAfter string
+	// CommitScanned is called with the hash of each commit once all of its files have been sent for scanning.
+	CommitScanned func(hash string)
 }
 
 type ScanOption func(*ScanOptions)
@@ -45,6 +50,18 @@ func ScanOptionLogOptions(logOptions *git.LogOptions) ScanOption {
 	}
 }
 
+func ScanOptionResumeAfter(hash string) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.ResumeAfter = hash
+	}
+}
+
+func ScanOptionCommitScanned(commitScanned func(hash string)) ScanOption {
+	return func(scanOptions *ScanOptions) {
+		scanOptions.CommitScanned = commitScanned
+	}
+}
+
 func NewScanOptions(options ...ScanOption) *ScanOptions {
 	scanOptions := &ScanOptions{
 		Filter:   common.FilterEmpty(),
@@ -60,3 +77,15 @@ func NewScanOptions(options ...ScanOption) *ScanOptions {
 	}
 	return scanOptions
 }
+
+// NewResumableScanOptions returns scan options that continue the scan of a repo after the last commit recorded as
+// the unit's position in the progress, and record the position as commits are scanned.
+func NewResumableScanOptions(progress *sources.Progress, unit string, options ...ScanOption) *ScanOptions {
+	options = append(options,
+		ScanOptionResumeAfter(progress.UnitPosition(unit)),
+		ScanOptionCommitScanned(func(hash string) {
+			progress.SetUnitPosition(unit, hash)
+		}),
+	)
+	return NewScanOptions(options...)
+}
@@ -319,6 +319,10 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 			if !strings.HasSuffix(repoURL, ".git") {
 				return
 			}
+			if s.UnitCompleted(repoURL) {
+				s.log.WithField("repo", repoURL).Debug("skipping repo, it was scanned completely before the scan was resumed")
+				return
+			}
 
 			s.log.WithField("repo", repoURL).Debugf("attempting to clone repo %d/%d", i+1, len(s.repos))
 			var path string
@@ -346,7 +350,7 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 				return
 			}
 			// Base and head will only exist from incoming webhooks.
-			scanOptions := git.NewScanOptions(
+			scanOptions := git.NewResumableScanOptions(&s.Progress, repoURL,
 				git.ScanOptionBaseHash(s.conn.Base),
 				git.ScanOptionHeadCommit(s.conn.Head),
 			)
@@ -354,6 +358,8 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 			err = s.git.ScanRepo(ctx, repo, path, scanOptions, chunksChan)
 			if err != nil {
 				log.WithError(err).Errorf("unable to scan repo, continuing")
+			} else if !common.IsDone(ctx) {
+				s.CompleteUnit(repoURL)
 			}
 			scanned += 1
 			logrus.Debugf("scanned %d/%d repos", scanned, len(s.repos))
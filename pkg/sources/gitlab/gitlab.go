@@ -284,6 +284,11 @@ func (s *Source) scanRepos(ctx context.Context, chunksChan chan *sources.Chunk,
 				return
 			}
 			s.SetProgressComplete(i, len(repos), fmt.Sprintf("Repo: %s", repoURL), "")
+			unit := repoURL.String()
+			if s.UnitCompleted(unit) {
+				log.Debugf("Skipping repo %s, it was scanned completely before the scan was resumed", unit)
+				return
+			}
 
 			var path string
 			var repo *gogit.Repository
@@ -307,13 +312,16 @@ func (s *Source) scanRepos(ctx context.Context, chunksChan chan *sources.Chunk,
 				return
 			}
 			log.Debugf("Starting to scan repo %d/%d: %s", i+1, len(repos), repoURL.String())
-			err = s.git.ScanRepo(ctx, repo, path, git.NewScanOptions(), chunksChan)
+			err = s.git.ScanRepo(ctx, repo, path, git.NewResumableScanOptions(&s.Progress, unit), chunksChan)
 			if err != nil {
 				errsMut.Lock()
 				errs = append(errs, err)
 				errsMut.Unlock()
 				return
 			}
+			if !common.IsDone(ctx) {
+				s.CompleteUnit(unit)
+			}
 			log.Debugf("Completed scanning repo %d/%d: %s", i+1, len(repos), repoURL.String())
 		}(ctx, u, i)
 	}
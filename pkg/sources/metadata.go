@@ -0,0 +1,25 @@
+package sources
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
+)
+
+// MetadataFile returns the file in the metadata, or an empty string if its source doesn't report one.
+func MetadataFile(metadata *source_metadatapb.MetaData) string {
+	if metadata == nil {
+		return ""
+	}
+	m := metadata.ProtoReflect()
+	data := m.WhichOneof(m.Descriptor().Oneofs().ByName("data"))
+	if data == nil || data.Kind() != protoreflect.MessageKind {
+		return ""
+	}
+	fields := m.Get(data).Message()
+	file := fields.Descriptor().Fields().ByName("file")
+	if file == nil || file.Kind() != protoreflect.StringKind {
+		return ""
+	}
+	return fields.Get(file).String()
+}
@@ -0,0 +1,122 @@
+package sources
+
+import (
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ResumeInfo is what a source needs to continue an interrupted scan. It is stored JSON encoded in
+// Progress.EncodedResumeInfo.
+//
+// A source splits its work into units, such as repositories, buckets or directories. Units that have been scanned
+// completely are skipped when resuming. Units that are in progress may record the last item, such as a commit or an
+// S3 key, after which everything still has to be scanned.
+type ResumeInfo struct {
+	// Completed holds the units that have been scanned completely.
+	Completed []string `json:"completed,omitempty"`
+	// Positions holds the last item processed in each unit that is in progress.
+	Positions map[string]string `json:"positions,omitempty"`
+}
+
+func decodeResumeInfo(encoded string) ResumeInfo {
+	var info ResumeInfo
+	if encoded == "" {
+		return info
+	}
+	if err := json.Unmarshal([]byte(encoded), &info); err != nil {
+		logrus.WithError(err).Warn("could not decode resume info, starting from scratch")
+		return ResumeInfo{}
+	}
+	return info
+}
+
+// SetResumeInfo sets the encoded resume info the source continues from. It must be called before the source starts
+// sending chunks.
+func (p *Progress) SetResumeInfo(encodedResumeInfo string) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.setEncodedResumeInfo(encodedResumeInfo)
+}
+
+func (p *Progress) setEncodedResumeInfo(encodedResumeInfo string) {
+	p.EncodedResumeInfo = encodedResumeInfo
+	p.resumeInfo = nil
+	p.resumeInfoChanged = false
+}
+
+// GetResumeInfo returns the current encoded resume info.
+func (p *Progress) GetResumeInfo() string {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if p.resumeInfoChanged {
+		encoded, err := json.Marshal(p.resumeInfo)
+		if err != nil {
+			logrus.WithError(err).Error("could not encode resume info")
+		} else {
+			p.EncodedResumeInfo = string(encoded)
+			p.resumeInfoChanged = false
+		}
+	}
+	return p.EncodedResumeInfo
+}
+
+// decodedResumeInfo returns the decoded resume info, decoding it the first time it's needed. The mutex must be held.
+func (p *Progress) decodedResumeInfo() *ResumeInfo {
+	if p.resumeInfo == nil {
+		info := decodeResumeInfo(p.EncodedResumeInfo)
+		p.resumeInfo = &info
+	}
+	return p.resumeInfo
+}
+
+// updateResumeInfo applies the update to the decoded resume info. It's encoded again when it is read.
+func (p *Progress) updateResumeInfo(update func(*ResumeInfo)) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	update(p.decodedResumeInfo())
+	p.resumeInfoChanged = true
+}
+
+// UnitCompleted returns true if the unit was scanned completely.
+func (p *Progress) UnitCompleted(unit string) bool {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	for _, completed := range p.decodedResumeInfo().Completed {
+		if completed == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// UnitPosition returns the last item processed in the unit, or an empty string if there is none.
+func (p *Progress) UnitPosition(unit string) string {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.decodedResumeInfo().Positions[unit]
+}
+
+// CompleteUnit records that the unit was scanned completely.
+func (p *Progress) CompleteUnit(unit string) {
+	p.updateResumeInfo(func(info *ResumeInfo) {
+		delete(info.Positions, unit)
+		for _, completed := range info.Completed {
+			if completed == unit {
+				return
+			}
+		}
+		info.Completed = append(info.Completed, unit)
+	})
+}
+
+// SetUnitPosition records the last item processed in the unit. Everything up to and including it is skipped when
+// resuming.
+func (p *Progress) SetUnitPosition(unit, position string) {
+	p.updateResumeInfo(func(info *ResumeInfo) {
+		if info.Positions == nil {
+			info.Positions = map[string]string{}
+		}
+		info.Positions[unit] = position
+	})
+}
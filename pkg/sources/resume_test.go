@@ -0,0 +1,59 @@
+package sources
+
+import "testing"
+
+func TestProgressResumeInfo(t *testing.T) {
+	var p Progress
+	p.SetUnitPosition("repo-a", "abc123")
+	p.SetUnitPosition("repo-b", "def456")
+	p.CompleteUnit("repo-a")
+	p.CompleteUnit("repo-a")
+
+	// The encoded resume info carries the state over to a new run.
+	var resumed Progress
+	resumed.SetResumeInfo(p.GetResumeInfo())
+
+	if !resumed.UnitCompleted("repo-a") {
+		t.Error("repo-a should be completed")
+	}
+	if resumed.UnitCompleted("repo-b") {
+		t.Error("repo-b should not be completed")
+	}
+	if got := resumed.UnitPosition("repo-a"); got != "" {
+		t.Errorf("got position %q for the completed repo-a, want none", got)
+	}
+	if got := resumed.UnitPosition("repo-b"); got != "def456" {
+		t.Errorf("got position %q for repo-b, want %q", got, "def456")
+	}
+	if got := decodeResumeInfo(resumed.GetResumeInfo()).Completed; len(got) != 1 {
+		t.Errorf("got completed units %v, want only repo-a", got)
+	}
+}
+
+func TestProgressInvalidResumeInfo(t *testing.T) {
+	var p Progress
+	p.SetResumeInfo("not json")
+	if p.UnitCompleted("repo-a") || p.UnitPosition("repo-a") != "" {
+		t.Error("invalid resume info should start from scratch")
+	}
+	p.CompleteUnit("repo-a")
+	if !p.UnitCompleted("repo-a") {
+		t.Error("repo-a should be completed")
+	}
+}
+
+func TestProgressResumeInfoUpdatedAfterRead(t *testing.T) {
+	var p Progress
+	p.SetUnitPosition("repo-a", "abc123")
+	first := p.GetResumeInfo()
+	p.SetUnitPosition("repo-a", "def456")
+	if got := decodeResumeInfo(p.GetResumeInfo()).Positions["repo-a"]; got != "def456" {
+		t.Errorf("got position %q, want %q", got, "def456")
+	}
+
+	// Setting the encoded resume info replaces the updates.
+	p.SetProgressComplete(1, 2, "", first)
+	if got := p.UnitPosition("repo-a"); got != "abc123" {
+		t.Errorf("got position %q, want %q", got, "abc123")
+	}
+}
@@ -1,9 +1,10 @@
 package s3
 
 import (
+	"bufio"
 	"context"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"github.com/go-errors/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/common"
+	"github.com/trufflesecurity/trufflehog/v3/pkg/handlers"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/source_metadatapb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/pb/sourcespb"
 	"github.com/trufflesecurity/trufflehog/v3/pkg/sanitizer"
@@ -25,6 +27,17 @@ import (
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
+// objectTimeout is the maximum time spent downloading a single object. The time spent waiting for its chunks to be
+// scanned isn't counted. Archives are bounded by the archive timeout instead.
+const objectTimeout = 5 * time.Second
+
+// maxObjectSize is the size of the largest object that is scanned. Archives are bounded by the archive size limit
+// instead.
+var maxObjectSize = int64(10 * common.MB)
+
+// errSkipFile stops chunking an object that shouldn't be scanned.
+var errSkipFile = errors.New("skip file")
+
 type Source struct {
 	name        string
 	sourceId    int64
@@ -139,6 +152,10 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 		}
 
 		s.SetProgressComplete(i, len(bucketsToScan), fmt.Sprintf("Bucket: %s", bucket), "")
+		if s.UnitCompleted(bucket) {
+			s.log.Debugf("Skipping bucket %s, it was scanned completely before the scan was resumed", bucket)
+			continue
+		}
 
 		s.log.Debugf("Scanning bucket: %s", bucket)
 		region, err := s3manager.GetBucketRegionWithClient(context.Background(), client, bucket)
@@ -159,10 +176,21 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 		//pf := "public"
 		errorCount := sync.Map{}
 
+		input := &s3.ListObjectsV2Input{Bucket: &bucket}
+		// Keys are listed in order, so a resumed scan starts after the last page that was scanned completely.
+		if startAfter := s.UnitPosition(bucket); startAfter != "" {
+			input.StartAfter = &startAfter
+		}
 		err = regionalClient.ListObjectsV2PagesWithContext(
-			ctx, &s3.ListObjectsV2Input{Bucket: &bucket},
+			ctx, input,
 			func(page *s3.ListObjectsV2Output, last bool) bool {
 				s.pageChunker(ctx, regionalClient, chunksChan, bucket, page, &errorCount)
+				if common.IsDone(ctx) {
+					return false
+				}
+				if len(page.Contents) > 0 {
+					s.SetUnitPosition(bucket, *page.Contents[len(page.Contents)-1].Key)
+				}
 				return true
 			})
 
@@ -170,7 +198,10 @@ func (s *Source) Chunks(ctx context.Context, chunksChan chan *sources.Chunk) err
 			s.log.WithError(err).Errorf("could not list objects in s3 bucket: %s", bucket)
 			return errors.WrapPrefix(err, fmt.Sprintf("could not list objects in s3 bucket: %s", bucket), 0)
 		}
-
+		if common.IsDone(ctx) {
+			return nil
+		}
+		s.CompleteUnit(bucket)
 	}
 
 	return nil
@@ -214,7 +245,8 @@ func (s *Source) pageChunker(ctx context.Context, client *s3.S3, chunksChan chan
 			}
 
 			// ignore large files
-			if *obj.Size > int64(10*common.MB) {
+			archiveLimits := handlers.CurrentLimits()
+			if *obj.Size > maxObjectSize && *obj.Size > archiveLimits.MaxSize {
 				return
 			}
 
@@ -225,11 +257,18 @@ func (s *Source) pageChunker(ctx context.Context, client *s3.S3, chunksChan chan
 
 			//files break with spaces, must replace with +
 			//objKey := strings.ReplaceAll(*obj.Key, " ", "+")
-			ctx, cancel := context.WithTimeout(ctx, time.Second*5)
+			// The object is streamed into chunks as they are scanned, so the timeout only covers the time spent
+			// waiting on S3.
+			downloadCtx, cancel := context.WithCancel(ctx)
 			defer cancel()
-			res, err := client.GetObjectWithContext(ctx, &s3.GetObjectInput{
-				Bucket: &bucket,
-				Key:    obj.Key,
+			budget := &downloadBudget{remaining: objectTimeout, cancel: cancel}
+			var res *s3.GetObjectOutput
+			var err error
+			budget.spend(func() {
+				res, err = client.GetObjectWithContext(downloadCtx, &s3.GetObjectInput{
+					Bucket: &bucket,
+					Key:    obj.Key,
+				})
 			})
 			if err != nil {
 				if !strings.Contains(err.Error(), "AccessDenied") {
@@ -253,7 +292,63 @@ func (s *Source) pageChunker(ctx context.Context, client *s3.S3, chunksChan chan
 				log.Debugf("Error Counts: %s:%s", prefix, nErr)
 				return
 			}
-			body, err := ioutil.ReadAll(res.Body)
+			defer res.Body.Close()
+
+			body, archive, ok := objectBody(res.Body, *obj.Size, budget, archiveLimits)
+			if !ok {
+				log.Debugf("Skipped large object: %s", *obj.Key)
+				return
+			}
+			if archive {
+				// The download of an archive is stopped with its extraction.
+				timer := time.AfterFunc(archiveLimits.Timeout, cancel)
+				defer timer.Stop()
+			}
+
+			email := "Unknown"
+			if obj.Owner != nil {
+				email = *obj.Owner.DisplayName
+			}
+			modified := obj.LastModified.String()
+			err = handlers.HandleFile(ctx, *obj.Key, body, func(ctx context.Context, file string, r io.Reader) error {
+				err := sources.ChunkReader(ctx, r, func(data []byte, pos sources.ChunkPosition) error {
+					// ignore files that don't have secrets
+					if pos.Offset == 0 && common.SkipFile(file, data) {
+						return errSkipFile
+					}
+					return sources.SendChunk(ctx, chunksChan, &sources.Chunk{
+						SourceType: s.Type(),
+						SourceName: s.name,
+						SourceID:   s.SourceID(),
+						Data:       data,
+						SourceMetadata: &source_metadatapb.MetaData{
+							Data: &source_metadatapb.MetaData_S3{
+								S3: &source_metadatapb.S3{
+									Bucket:    bucket,
+									File:      sanitizer.UTF8(file),
+									Link:      sanitizer.UTF8(makeS3Link(bucket, *client.Config.Region, *obj.Key)),
+									Email:     sanitizer.UTF8(email),
+									Timestamp: sanitizer.UTF8(modified),
+									Line:      pos.Line,
+									Offset:    pos.Offset,
+								},
+							},
+						},
+						Verify: s.verify,
+					})
+				})
+				// Skipping a file in an archive doesn't skip the rest of the archive.
+				if errors.Is(err, errSkipFile) {
+					return nil
+				}
+				return err
+			})
+			// An archive that takes too long to extract is skipped like one that is too large, rather than counted as an
+			// error of its prefix.
+			if err != nil && archive && ctx.Err() == nil && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+				log.Debugf("extracting %s took over %s, skipping the rest", *obj.Key, archiveLimits.Timeout)
+				return
+			}
 			if err != nil {
 				s.log.WithError(err).Error("could not read S3 object body")
 				nErr, ok := errorCount.Load(prefix)
@@ -274,34 +369,6 @@ func (s *Source) pageChunker(ctx context.Context, client *s3.S3, chunksChan chan
 				return
 			}
 
-			// ignore files that don't have secrets
-			if common.SkipFile(*obj.Key, body) {
-				return
-			}
-
-			email := "Unknown"
-			if obj.Owner != nil {
-				email = *obj.Owner.DisplayName
-			}
-			modified := obj.LastModified.String()
-			chunk := sources.Chunk{
-				SourceType: s.Type(),
-				SourceName: s.name,
-				SourceID:   s.SourceID(),
-				Data:       body,
-				SourceMetadata: &source_metadatapb.MetaData{
-					Data: &source_metadatapb.MetaData_S3{
-						S3: &source_metadatapb.S3{
-							Bucket:    bucket,
-							File:      sanitizer.UTF8(*obj.Key),
-							Link:      sanitizer.UTF8(makeS3Link(bucket, *client.Config.Region, *obj.Key)),
-							Email:     sanitizer.UTF8(email),
-							Timestamp: sanitizer.UTF8(modified),
-						},
-					},
-				},
-				Verify: s.verify,
-			}
 			nErr, ok = errorCount.Load(prefix)
 			if !ok {
 				nErr = 0
@@ -309,12 +376,54 @@ func (s *Source) pageChunker(ctx context.Context, client *s3.S3, chunksChan chan
 			if nErr.(int) > 0 {
 				errorCount.Store(prefix, 0)
 			}
-			chunksChan <- &chunk
 		}(ctx, &wg, sem, obj)
 	}
 	wg.Wait()
 }
 
+// objectBody returns the reader of the body of an object and whether the object is an archive. ok is false if the
+// object is too large to scan. Archives are extracted with the archive limits, so the download budget only applies to
+// other objects after their first bytes are read.
+func objectBody(body io.Reader, size int64, budget *downloadBudget, archiveLimits handlers.Limits) (r io.Reader, archive bool, ok bool) {
+	reader := bufio.NewReaderSize(body, handlers.HeaderSize)
+	var header []byte
+	budget.spend(func() {
+		header, _ = reader.Peek(handlers.HeaderSize)
+	})
+	if handlers.IsArchive(header) {
+		return reader, true, size <= archiveLimits.MaxSize
+	}
+	return &budgetReader{r: reader, budget: budget}, false, size <= maxObjectSize
+}
+
+// downloadBudget cancels the download of an object once the time spent waiting on S3 adds up to its timeout.
+type downloadBudget struct {
+	remaining time.Duration
+	cancel    context.CancelFunc
+}
+
+// spend runs f, which waits on S3, and cancels the download if it runs out of time.
+func (b *downloadBudget) spend(f func()) {
+	start := time.Now()
+	timer := time.AfterFunc(b.remaining, b.cancel)
+	f()
+	timer.Stop()
+	b.remaining -= time.Since(start)
+}
+
+// budgetReader reads the body of an object, spending the download budget while it waits on S3.
+type budgetReader struct {
+	r      io.Reader
+	budget *downloadBudget
+}
+
+func (r *budgetReader) Read(p []byte) (n int, err error) {
+	r.budget.spend(func() {
+		n, err = r.r.Read(p)
+	})
+	return n, err
+}
+
 // S3 links currently have the general format of:
 // https://[bucket].s3[.region unless us-east-1].amazonaws.com/[key]
 func makeS3Link(bucket, region, key string) string {
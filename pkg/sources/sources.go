@@ -50,19 +50,27 @@ type Progress struct {
 	EncodedResumeInfo string
 	SectionsCompleted int32
 	SectionsRemaining int32
+
+	// resumeInfo is the decoded resume info while units are being updated. EncodedResumeInfo is only brought up to
+	// date with it when the resume info is read, so that updates don't encode it every time.
+	resumeInfo        *ResumeInfo
+	resumeInfoChanged bool
 }
 
 // SetProgressComplete sets job progress information for a running job based on the highest level objects in the source.
 // i is the current iteration in the loop of target scope
 // scope should be the len(scopedItems)
 // message is the public facing user information about the current progress
-// encodedResumeInfo is an optional string representing any information necessary to resume the job if interrupted
+// encodedResumeInfo is an optional string representing any information necessary to resume the job if interrupted,
+// the current resume info is kept if it is empty
 func (p *Progress) SetProgressComplete(i, scope int, message, encodedResumeInfo string) {
 	p.mut.Lock()
 	defer p.mut.Unlock()
 
 	p.Message = message
-	p.EncodedResumeInfo = encodedResumeInfo
+	if encodedResumeInfo != "" {
+		p.setEncodedResumeInfo(encodedResumeInfo)
+	}
 	p.SectionsCompleted = int32(i)
 	p.SectionsRemaining = int32(scope)
 	p.PercentComplete = int64((float64(i) / float64(scope)) * 100)